@@ -0,0 +1,18 @@
+module github.com/shengyanli1982/law/adapters/lawklog
+
+go 1.20
+
+replace github.com/shengyanli1982/law => ../../
+
+require (
+	github.com/go-logr/logr v1.3.0
+	github.com/shengyanli1982/law v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.8.4
+	k8s.io/klog/v2 v2.110.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,184 @@
+// Package lawklog 提供 klog 与 law 的集成。
+//
+// klog.SetOutput 会把写入器包装成 Flush 为空操作的内部类型，klog.Flush 无法刷新 law。
+// Install 改为通过 klog.SetLoggerWithOptions 接管 klog 的输出：非结构化日志（Info、Infof 等）
+// 保持 klog 自身的格式写入 law，结构化日志（InfoS、ErrorS 等）由 LogSink 编码后写入 law，
+// 并且 klog.Flush 会刷新 law。
+package lawklog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+)
+
+// Target 定义了日志的写入目标，*law.WriteAsyncer 实现了该接口
+type Target interface {
+	io.Writer
+
+	// Flush 将已写入的数据刷新到底层 io.Writer
+	Flush() error
+}
+
+// Install 将 klog 的全部输出重定向到 target，klog.Flush 会刷新 target。
+// 调用 Uninstall 可以恢复 klog 的默认输出。
+func Install(target Target) {
+	klog.SetLoggerWithOptions(
+		logr.New(NewLogSink(target)),
+		klog.WriteKlogBuffer(func(data []byte) {
+			_, _ = target.Write(data)
+		}),
+		klog.FlushLogger(func() {
+			_ = target.Flush()
+		}),
+	)
+}
+
+// Uninstall 移除 Install 设置的输出，klog 恢复写入自身的输出
+func Uninstall() {
+	klog.ClearLogger()
+}
+
+// bufferPool 复用编码日志行的缓冲区
+var bufferPool = sync.Pool{
+	New: func() any {
+		return bytes.NewBuffer(make([]byte, 0, 256))
+	},
+}
+
+// LogSink 是将结构化日志编码为 logfmt 风格文本并写入 law 的 logr.LogSink
+type LogSink struct {
+	target    Target
+	name      string
+	values    []any
+	verbosity int
+	limited   bool
+}
+
+// NewLogSink 创建新的 LogSink，默认记录所有详细级别
+func NewLogSink(target Target) *LogSink {
+	return &LogSink{target: target}
+}
+
+// WithVerbosity 设置记录的最大详细级别，大于该级别的 Info 日志会被忽略
+func (s *LogSink) WithVerbosity(v int) *LogSink {
+	s.verbosity = v
+	s.limited = true
+	return s
+}
+
+// Init 实现 logr.LogSink，无需运行时信息
+func (s *LogSink) Init(logr.RuntimeInfo) {}
+
+// Enabled 判断指定详细级别的日志是否需要记录
+func (s *LogSink) Enabled(level int) bool {
+	return !s.limited || level <= s.verbosity
+}
+
+// Info 记录一条普通日志
+func (s *LogSink) Info(level int, msg string, keysAndValues ...any) {
+	s.write("info", level, nil, msg, keysAndValues)
+}
+
+// Error 记录一条错误日志
+func (s *LogSink) Error(err error, msg string, keysAndValues ...any) {
+	s.write("error", 0, err, msg, keysAndValues)
+}
+
+// WithValues 返回附加了键值对的新 LogSink
+func (s *LogSink) WithValues(keysAndValues ...any) logr.LogSink {
+	clone := *s
+	clone.values = append(append(make([]any, 0, len(s.values)+len(keysAndValues)), s.values...), keysAndValues...)
+	return &clone
+}
+
+// WithName 返回追加了名称的新 LogSink，名称之间以 "/" 分隔
+func (s *LogSink) WithName(name string) logr.LogSink {
+	clone := *s
+	if clone.name == "" {
+		clone.name = name
+	} else {
+		clone.name += "/" + name
+	}
+	return &clone
+}
+
+// write 编码一行日志并写入 target
+func (s *LogSink) write(level string, v int, err error, msg string, keysAndValues []any) {
+	buff := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		buff.Reset()
+		bufferPool.Put(buff)
+	}()
+
+	buff.WriteString("ts=")
+	buff.WriteString(time.Now().Format(time.RFC3339Nano))
+	buff.WriteString(" level=")
+	buff.WriteString(level)
+	if v > 0 {
+		buff.WriteString(" v=")
+		buff.WriteString(strconv.Itoa(v))
+	}
+	if s.name != "" {
+		writeKeyValue(buff, "logger", s.name)
+	}
+	writeKeyValue(buff, "msg", msg)
+	if err != nil {
+		writeKeyValue(buff, "err", err)
+	}
+	writeKeyValues(buff, s.values)
+	writeKeyValues(buff, keysAndValues)
+	buff.WriteByte('\n')
+
+	_, _ = s.target.Write(buff.Bytes())
+}
+
+// writeKeyValues 编码键值对列表，缺少值的键以 "(MISSING)" 补齐
+func writeKeyValues(buff *bytes.Buffer, keysAndValues []any) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		var value any = "(MISSING)"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		writeKeyValue(buff, key, value)
+	}
+}
+
+// writeKeyValue 编码一个键值对，字符串值在需要时加引号
+func writeKeyValue(buff *bytes.Buffer, key string, value any) {
+	buff.WriteByte(' ')
+	buff.WriteString(key)
+	buff.WriteByte('=')
+
+	// fmt 优先使用 Error 和 String 方法，并捕获其中的 panic（例如带类型的 nil 错误）
+	str, ok := value.(string)
+	if !ok {
+		str = fmt.Sprint(value)
+	}
+
+	if needsQuote(str) {
+		buff.WriteString(strconv.Quote(str))
+	} else {
+		buff.WriteString(str)
+	}
+}
+
+// needsQuote 判断值是否包含空白、引号、等号或不可打印字符
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '"' || r == '=' || r == 0x7f || !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
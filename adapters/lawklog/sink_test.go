@@ -0,0 +1,118 @@
+package lawklog
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	law "github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/klog/v2"
+)
+
+// memorySink 是一个并发安全的内存输出
+type memorySink struct {
+	mu   sync.Mutex
+	buff bytes.Buffer
+}
+
+func (s *memorySink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.Write(p)
+}
+
+func (s *memorySink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.String()
+}
+
+func newWriter(sink *memorySink) *law.WriteAsyncer {
+	return law.NewWriteAsyncer(sink, law.NewConfig().WithIdleTimeout(time.Hour))
+}
+
+// stripTimestamp 去掉日志行开头的 ts 字段
+func stripTimestamp(line string) string {
+	if i := strings.Index(line, " "); strings.HasPrefix(line, "ts=") && i > 0 {
+		return line[i+1:]
+	}
+	return line
+}
+
+func TestLogSink_Format(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+
+	logger := logr.New(NewLogSink(aw)).WithName("api").WithValues("service", "users")
+	logger.Info("hello world", "id", 1, "path", "/v1")
+	logger.V(2).Info("verbose")
+	logger.Error(errors.New("boom"), "failed", "key")
+	aw.Stop()
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `level=info logger=api msg="hello world" service=users id=1 path=/v1`, stripTimestamp(lines[0]))
+	assert.Equal(t, `level=info v=2 logger=api msg=verbose service=users`, stripTimestamp(lines[1]))
+	assert.Equal(t, `level=error logger=api msg=failed err=boom service=users key=(MISSING)`, stripTimestamp(lines[2]))
+}
+
+func TestLogSink_TypedNil(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+
+	// 带类型的 nil 值调用 Error 或 String 时会 panic，不能让调用方崩溃
+	var pathErr *os.PathError
+	var addr *net.TCPAddr
+	logger := logr.New(NewLogSink(aw))
+	assert.NotPanics(t, func() {
+		logger.Info("typed nil", "err", pathErr, "addr", addr)
+		logger.Error(pathErr, "failed")
+	})
+	aw.Stop()
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, `level=info msg="typed nil" err=<nil> addr=<nil>`, stripTimestamp(lines[0]))
+	assert.Equal(t, `level=error msg=failed err=<nil>`, stripTimestamp(lines[1]))
+}
+
+func TestLogSink_Verbosity(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+
+	logger := logr.New(NewLogSink(aw).WithVerbosity(1))
+	logger.V(1).Info("kept")
+	logger.V(2).Info("dropped")
+	aw.Stop()
+
+	assert.Contains(t, sink.String(), "msg=kept")
+	assert.NotContains(t, sink.String(), "dropped")
+}
+
+func TestInstall(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+	defer aw.Stop()
+
+	Install(aw)
+	defer Uninstall()
+
+	klog.Info("unstructured")
+	klog.InfoS("structured", "id", 7)
+	assert.Equal(t, "", sink.String())
+
+	klog.Flush()
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "I"))
+	assert.True(t, strings.HasSuffix(lines[0], "] unstructured"))
+	assert.Equal(t, "level=info msg=structured id=7", stripTimestamp(lines[1]))
+}
@@ -0,0 +1,18 @@
+module github.com/shengyanli1982/law/adapters/lawlogrus
+
+go 1.20
+
+replace github.com/shengyanli1982/law => ../../
+
+require (
+	github.com/shengyanli1982/law v0.0.0-00010101000000-000000000000
+	github.com/sirupsen/logrus v1.9.3
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,118 @@
+// Package lawlogrus 提供 logrus 与 law 的集成。
+//
+// Hook 是一个 logrus.Hook，它在调用方协程上格式化日志条目，然后按级别把结果写入不同的写入器。
+// 通常配合 logger.SetOutput(io.Discard) 使用，让所有输出都经过 Hook。
+package lawlogrus
+
+import (
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Target 定义了日志的写入目标，*law.WriteAsyncer 实现了该接口
+type Target interface {
+	io.Writer
+
+	// Flush 将已写入的数据刷新到底层 io.Writer
+	Flush() error
+}
+
+// Hook 按日志级别路由的 logrus.Hook。
+// 路由和格式化器应在注册到 logrus 前配置完成，之后可被多个协程并发使用。
+type Hook struct {
+	fallback  Target
+	routes    map[logrus.Level]Target
+	levels    []logrus.Level
+	formatter logrus.Formatter
+}
+
+// NewHook 创建新的 Hook，未单独配置路由的级别都写入 fallback。
+// levels 为空时对所有级别生效。
+func NewHook(fallback Target, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &Hook{
+		fallback: fallback,
+		routes:   make(map[logrus.Level]Target),
+		levels:   levels,
+	}
+}
+
+// WithRoute 将指定级别的日志路由到 target
+func (h *Hook) WithRoute(level logrus.Level, target Target) *Hook {
+	h.routes[level] = target
+	return h
+}
+
+// WithFormatter 设置格式化器，为 nil 时使用日志条目所属 Logger 的格式化器
+func (h *Hook) WithFormatter(formatter logrus.Formatter) *Hook {
+	h.formatter = formatter
+	return h
+}
+
+// Levels 返回 Hook 生效的级别
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire 格式化日志条目并写入对应级别的写入器
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	formatter := h.formatter
+	if formatter == nil {
+		formatter = entry.Logger.Formatter
+	}
+
+	content, err := formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.target(entry.Level).Write(content)
+	return err
+}
+
+// target 返回指定级别的写入目标
+func (h *Hook) target(level logrus.Level) Target {
+	if t, ok := h.routes[level]; ok {
+		return t
+	}
+	return h.fallback
+}
+
+// Flush 刷新所有写入目标，同一个目标只刷新一次，返回所有刷新失败的错误。
+// 适合在程序退出前或 logrus.RegisterExitHandler 中调用。
+func (h *Hook) Flush() error {
+	targets := make([]Target, 0, len(h.routes)+1)
+	targets = append(targets, h.fallback)
+	for _, t := range h.routes {
+		targets = append(targets, t)
+	}
+	return flushUnique(targets)
+}
+
+// flushUnique 依次刷新 targets 中的每个写入目标，同一个目标只刷新一次，返回所有刷新失败的错误。
+// 指针按地址判断是否为同一个目标；动态类型不可比较的目标总是单独刷新，判断时不会 panic。nil 目标被跳过。
+func flushUnique(targets []Target) error {
+	seen := make(map[Target]struct{}, len(targets))
+	var errs []error
+	for _, t := range targets {
+		v := reflect.ValueOf(t)
+		if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+			continue
+		}
+		if v.Comparable() {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+		}
+		if err := t.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package lawlogrus
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink 是一个并发安全的内存输出
+type memorySink struct {
+	mu   sync.Mutex
+	buff bytes.Buffer
+}
+
+func (s *memorySink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.Write(p)
+}
+
+func (s *memorySink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.String()
+}
+
+func newWriter(sink *memorySink) *law.WriteAsyncer {
+	return law.NewWriteAsyncer(sink, law.NewConfig().WithIdleTimeout(time.Hour))
+}
+
+func newLogger(hook logrus.Hook) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true, DisableColors: true})
+	logger.AddHook(hook)
+	return logger
+}
+
+func TestHook_Route(t *testing.T) {
+	appSink, errSink := &memorySink{}, &memorySink{}
+	app, errw := newWriter(appSink), newWriter(errSink)
+
+	hook := NewHook(app).WithRoute(logrus.ErrorLevel, errw)
+	logger := newLogger(hook)
+
+	logger.WithField("id", 1).Info("hello")
+	logger.Error("failed")
+
+	assert.Nil(t, hook.Flush())
+	app.Stop()
+	errw.Stop()
+
+	assert.Equal(t, "level=info msg=hello id=1\n", appSink.String())
+	assert.Equal(t, "level=error msg=failed\n", errSink.String())
+}
+
+func TestHook_Levels(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+
+	hook := NewHook(aw, logrus.WarnLevel, logrus.ErrorLevel)
+	assert.Equal(t, []logrus.Level{logrus.WarnLevel, logrus.ErrorLevel}, hook.Levels())
+
+	logger := newLogger(hook)
+	logger.Info("skipped")
+	logger.Warn("kept")
+	aw.Stop()
+
+	assert.Equal(t, "level=warning msg=kept\n", sink.String())
+}
+
+func TestHook_Formatter(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+
+	hook := NewHook(aw).WithFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+	logger := newLogger(hook)
+	logger.Info("hello")
+	aw.Stop()
+
+	assert.Equal(t, `{"level":"info","msg":"hello"}`+"\n", sink.String())
+}
+
+func TestHook_Closed(t *testing.T) {
+	aw := newWriter(&memorySink{})
+	aw.Stop()
+
+	hook := NewHook(aw)
+	entry := logrus.NewEntry(newLogger(hook))
+	entry.Level = logrus.InfoLevel
+	assert.ErrorIs(t, hook.Fire(entry), law.ErrorWriteAsyncerIsClosed)
+	assert.ErrorIs(t, hook.Flush(), law.ErrorWriteAsyncerIsClosed)
+}
+
+// sliceTarget 动态类型不可比较的写入目标
+type sliceTarget struct {
+	flushes *int
+	tags    []string
+}
+
+func (t sliceTarget) Write(p []byte) (int, error) { return len(p), nil }
+
+func (t sliceTarget) Flush() error {
+	*t.flushes++
+	return nil
+}
+
+func TestHook_FlushNonComparable(t *testing.T) {
+	flushes := 0
+	target := sliceTarget{flushes: &flushes, tags: []string{"app"}}
+
+	hook := NewHook(target).WithRoute(logrus.ErrorLevel, target)
+	assert.NotPanics(t, func() { assert.Nil(t, hook.Flush()) })
+	assert.Equal(t, 2, flushes)
+}
@@ -0,0 +1,20 @@
+module github.com/shengyanli1982/law/adapters/lawzerolog
+
+go 1.20
+
+replace github.com/shengyanli1982/law => ../../
+
+require (
+	github.com/rs/zerolog v1.31.0
+	github.com/shengyanli1982/law v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
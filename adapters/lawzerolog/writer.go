@@ -0,0 +1,126 @@
+// Package lawzerolog 提供 zerolog 与 law 的集成。
+//
+// LevelWriter 实现了 zerolog.LevelWriter，利用 zerolog 提供的日志级别将日志路由到不同的写入器，
+// 并可以让指定级别以上的日志同步落盘，而其余日志保持异步写入。
+package lawzerolog
+
+import (
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/rs/zerolog"
+)
+
+// Target 定义了日志的写入目标，*law.WriteAsyncer 实现了该接口
+type Target interface {
+	io.Writer
+
+	// Flush 将已写入的数据刷新到底层 io.Writer
+	Flush() error
+}
+
+// LevelWriter 按日志级别路由的 zerolog.LevelWriter。
+// 路由和同步级别应在交给 zerolog 使用前配置完成，之后可被多个协程并发使用。
+type LevelWriter struct {
+	fallback  Target
+	routes    map[zerolog.Level]Target
+	syncLevel zerolog.Level
+	sync      bool
+}
+
+// NewLevelWriter 创建新的 LevelWriter，未单独配置路由的级别都写入 fallback
+func NewLevelWriter(fallback Target) *LevelWriter {
+	return &LevelWriter{
+		fallback: fallback,
+		routes:   make(map[zerolog.Level]Target),
+	}
+}
+
+// WithRoute 将指定级别的日志路由到 target
+func (w *LevelWriter) WithRoute(level zerolog.Level, target Target) *LevelWriter {
+	w.routes[level] = target
+	return w
+}
+
+// WithSyncLevel 设置同步写入的最低级别。
+// 达到该级别的日志写入后会立即刷新对应的写入目标，调用返回时日志已经到达底层 io.Writer，
+// 并且与之前异步写入的日志保持顺序。NoLevel 和 Disabled 不参与比较。
+func (w *LevelWriter) WithSyncLevel(level zerolog.Level) *LevelWriter {
+	w.syncLevel = level
+	w.sync = true
+	return w
+}
+
+// target 返回指定级别的写入目标
+func (w *LevelWriter) target(level zerolog.Level) Target {
+	if t, ok := w.routes[level]; ok {
+		return t
+	}
+	return w.fallback
+}
+
+// isSync 判断指定级别是否需要同步写入
+func (w *LevelWriter) isSync(level zerolog.Level) bool {
+	return w.sync && level != zerolog.NoLevel && level != zerolog.Disabled && level >= w.syncLevel
+}
+
+// Write 写入没有级别信息的日志，写入 fallback
+func (w *LevelWriter) Write(p []byte) (int, error) {
+	return w.fallback.Write(p)
+}
+
+// WriteLevel 按级别写入日志
+func (w *LevelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	target := w.target(level)
+
+	n, err := target.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if w.isSync(level) {
+		if err := target.Flush(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Flush 刷新所有写入目标，同一个目标只刷新一次，返回所有刷新失败的错误
+func (w *LevelWriter) Flush() error {
+	return flushUnique(w.targets())
+}
+
+// targets 返回所有写入目标，可能包含重复的目标
+func (w *LevelWriter) targets() []Target {
+	targets := make([]Target, 0, len(w.routes)+1)
+	targets = append(targets, w.fallback)
+	for _, t := range w.routes {
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// flushUnique 依次刷新 targets 中的每个写入目标，同一个目标只刷新一次，返回所有刷新失败的错误。
+// 指针按地址判断是否为同一个目标；动态类型不可比较的目标总是单独刷新，判断时不会 panic。nil 目标被跳过。
+func flushUnique(targets []Target) error {
+	seen := make(map[Target]struct{}, len(targets))
+	var errs []error
+	for _, t := range targets {
+		v := reflect.ValueOf(t)
+		if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+			continue
+		}
+		if v.Comparable() {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+		}
+		if err := t.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
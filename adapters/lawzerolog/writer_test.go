@@ -0,0 +1,112 @@
+package lawzerolog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	law "github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink 是一个并发安全的内存输出
+type memorySink struct {
+	mu   sync.Mutex
+	buff bytes.Buffer
+}
+
+func (s *memorySink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.Write(p)
+}
+
+func (s *memorySink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.String()
+}
+
+func newWriter(sink *memorySink) *law.WriteAsyncer {
+	return law.NewWriteAsyncer(sink, law.NewConfig().WithIdleTimeout(time.Hour))
+}
+
+func TestLevelWriter_Route(t *testing.T) {
+	appSink, errSink := &memorySink{}, &memorySink{}
+	app, errw := newWriter(appSink), newWriter(errSink)
+
+	w := NewLevelWriter(app).WithRoute(zerolog.ErrorLevel, errw)
+	logger := zerolog.New(w)
+
+	logger.Info().Msg("info")
+	logger.Error().Msg("error")
+	logger.Log().Msg("nolevel")
+
+	app.Stop()
+	errw.Stop()
+
+	assert.Equal(t, `{"level":"info","message":"info"}`+"\n"+`{"message":"nolevel"}`+"\n", appSink.String())
+	assert.Equal(t, `{"level":"error","message":"error"}`+"\n", errSink.String())
+}
+
+func TestLevelWriter_SyncLevel(t *testing.T) {
+	sink := &memorySink{}
+	aw := newWriter(sink)
+	defer aw.Stop()
+
+	logger := zerolog.New(NewLevelWriter(aw).WithSyncLevel(zerolog.ErrorLevel))
+
+	logger.Info().Msg("async")
+	assert.Equal(t, "", sink.String())
+
+	logger.Error().Msg("sync")
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	assert.Equal(t, []string{`{"level":"info","message":"async"}`, `{"level":"error","message":"sync"}`}, lines)
+}
+
+func TestLevelWriter_Flush(t *testing.T) {
+	appSink, errSink := &memorySink{}, &memorySink{}
+	app, errw := newWriter(appSink), newWriter(errSink)
+	defer app.Stop()
+	defer errw.Stop()
+
+	w := NewLevelWriter(app).
+		WithRoute(zerolog.ErrorLevel, errw).
+		WithRoute(zerolog.FatalLevel, errw)
+	logger := zerolog.New(w)
+
+	logger.Info().Msg("info")
+	logger.Error().Msg("error")
+
+	assert.Nil(t, w.Flush())
+	assert.Contains(t, appSink.String(), "info")
+	assert.Contains(t, errSink.String(), "error")
+
+	app.Stop()
+	assert.ErrorIs(t, w.Flush(), law.ErrorWriteAsyncerIsClosed)
+}
+
+// sliceTarget 动态类型不可比较的写入目标
+type sliceTarget struct {
+	flushes *int
+	tags    []string
+}
+
+func (t sliceTarget) Write(p []byte) (int, error) { return len(p), nil }
+
+func (t sliceTarget) Flush() error {
+	*t.flushes++
+	return nil
+}
+
+func TestLevelWriter_FlushNonComparable(t *testing.T) {
+	flushes := 0
+	target := sliceTarget{flushes: &flushes, tags: []string{"app"}}
+
+	w := NewLevelWriter(target).WithRoute(zerolog.ErrorLevel, target)
+	assert.NotPanics(t, func() { assert.Nil(t, w.Flush()) })
+	assert.Equal(t, 2, flushes)
+}
@@ -0,0 +1,179 @@
+package law
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// 聚合器的默认配置
+const (
+	DefaultAggregateMaxBytes = 64 * 1024
+	DefaultAggregateTimeout  = 100 * time.Millisecond
+)
+
+// ContinuationFunc 判断一行是否是上一行的延续，line 不包含结尾的换行
+type ContinuationFunc func(line []byte) bool
+
+// IndentContinuation 将以空格或制表符开头的行视为延续行，适用于大多数堆栈信息
+func IndentContinuation(line []byte) bool {
+	return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+}
+
+// RegexpContinuation 返回将匹配 re 的行视为延续行的 ContinuationFunc
+func RegexpContinuation(re *regexp.Regexp) ContinuationFunc {
+	return func(line []byte) bool {
+		return re.Match(line)
+	}
+}
+
+// Aggregator 是位于 WriteAsyncer 之前的多行聚合器，它把延续行与之前的行合并为一条记录后再入队，
+// 避免一个生产者的多行日志（例如堆栈信息）被其他协程的日志打断。
+//
+// 每个生产者（例如一个 Logger）应使用自己的 Aggregator，多个 Aggregator 可以共享同一个 WriteAsyncer。
+// 每次 Write 都被视为以完整的行结束，缺少的换行会被补齐。
+// 一条记录在遇到下一条非延续行、超过 WithFlushTimeout 设置的时间没有新的行、超过最大长度，
+// 或调用 Flush、Close 时入队。
+type Aggregator struct {
+	writer       *WriteAsyncer
+	continuation ContinuationFunc
+	maxBytes     int
+	timeout      time.Duration
+
+	mu      sync.Mutex
+	pending *bytes.Buffer
+	timer   *time.Timer
+	closed  bool
+}
+
+// NewAggregator 创建写入 w 的聚合器，默认使用 IndentContinuation 判断延续行
+func NewAggregator(w *WriteAsyncer) *Aggregator {
+	return &Aggregator{
+		writer:       w,
+		continuation: IndentContinuation,
+		maxBytes:     DefaultAggregateMaxBytes,
+		timeout:      DefaultAggregateTimeout,
+	}
+}
+
+// WithContinuation 设置判断延续行的函数，为 nil 时使用 IndentContinuation
+func (a *Aggregator) WithContinuation(fn ContinuationFunc) *Aggregator {
+	if fn == nil {
+		fn = IndentContinuation
+	}
+	a.continuation = fn
+	return a
+}
+
+// WithMaxBytes 设置一条聚合记录的最大长度，超过时先将已聚合的内容入队，小于等于 0 表示不限制
+func (a *Aggregator) WithMaxBytes(n int) *Aggregator {
+	a.maxBytes = n
+	return a
+}
+
+// WithFlushTimeout 设置聚合记录在没有新的行时等待的最长时间，小于等于 0 表示只在 Flush 或 Close 时入队
+func (a *Aggregator) WithFlushTimeout(timeout time.Duration) *Aggregator {
+	a.timeout = timeout
+	return a
+}
+
+// Write 按行处理 p，返回 len(p)。已聚合的记录入队失败时停止处理，返回失败前已加入聚合记录的字节数和 WriteOwned 的错误，
+// 导致入队的行及之后的行不会被加入。
+func (a *Aggregator) Write(p []byte) (int, error) {
+	if p == nil {
+		return 0, ErrorWriteContentIsNil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return 0, ErrorWriteAsyncerIsClosed
+	}
+
+	var (
+		n   int
+		err error
+	)
+	for n < len(p) {
+		line, next := p[n:], len(p)
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line, next = line[:i], n+i+1
+		}
+
+		if err = a.appendLine(line); err != nil {
+			break
+		}
+		n = next
+	}
+
+	if a.pending != nil && a.timeout > 0 {
+		if a.timer == nil {
+			a.timer = time.AfterFunc(a.timeout, a.expire)
+		} else {
+			a.timer.Reset(a.timeout)
+		}
+	}
+
+	return n, err
+}
+
+// appendLine 将一行加入聚合记录，非延续行或超过最大长度时先将已聚合的内容入队，入队失败时不加入该行
+func (a *Aggregator) appendLine(line []byte) error {
+	if a.pending != nil {
+		full := a.maxBytes > 0 && a.pending.Len()+len(line)+1 > a.maxBytes
+		if full || !a.continuation(line) {
+			if err := a.emit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a.pending == nil {
+		a.pending = a.writer.AcquireBuffer()
+	}
+	a.pending.Write(line)
+	a.pending.WriteByte('\n')
+	return nil
+}
+
+// emit 将已聚合的记录交给写入器
+func (a *Aggregator) emit() error {
+	if a.pending == nil {
+		return nil
+	}
+	buff := a.pending
+	a.pending = nil
+	_, err := a.writer.WriteOwned(buff)
+	return err
+}
+
+// expire 在超时后将已聚合的记录入队
+func (a *Aggregator) expire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_ = a.emit()
+}
+
+// Flush 将已聚合的记录入队，不会刷新写入器
+func (a *Aggregator) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.emit()
+}
+
+// Close 将已聚合的记录入队并停止聚合器，不会停止写入器
+func (a *Aggregator) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	return a.emit()
+}
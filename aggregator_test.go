@@ -0,0 +1,111 @@
+package law
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_EnsureNewline(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithEnsureNewline(true))
+
+	n, err := w.Write([]byte("hello"))
+	assert.Equal(t, 5, n)
+	assert.Nil(t, err)
+	_, _ = w.Write([]byte("world\n"))
+	_, _ = w.WriteOwned(bytes.NewBufferString("owned"))
+	_ = w.WriteRecord(RecordFunc(func(b *bytes.Buffer) error {
+		b.WriteString("record")
+		return nil
+	}))
+	w.Stop()
+
+	assert.Equal(t, "hello\nworld\nowned\nrecord\n", buff.String())
+}
+
+func TestAggregator(t *testing.T) {
+	t.Run("indentation", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		agg := NewAggregator(w).WithFlushTimeout(0)
+
+		_, _ = agg.Write([]byte("panic: boom\n"))
+		_, _ = agg.Write([]byte("\tat main.go:10\n"))
+		_, _ = agg.Write([]byte("\tat main.go:20"))
+		_, _ = agg.Write([]byte("next line\n"))
+		assert.Nil(t, agg.Close())
+		w.Stop()
+
+		assert.Equal(t, "panic: boom\n\tat main.go:10\n\tat main.go:20\nnext line\n", buff.String())
+		assert.Equal(t, uint64(2), w.Stats().EnqueuedRecords)
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		agg := NewAggregator(w).
+			WithContinuation(RegexpContinuation(regexp.MustCompile(`^(\s+at |Caused by:)`))).
+			WithFlushTimeout(0)
+
+		_, _ = agg.Write([]byte("Exception in thread main\n    at A.run\nCaused by: IOException\n    at B.read\nINFO done\n"))
+		assert.Nil(t, agg.Close())
+		w.Stop()
+
+		assert.Equal(t, uint64(2), w.Stats().EnqueuedRecords)
+		assert.True(t, strings.HasSuffix(buff.String(), "at B.read\nINFO done\n"))
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		agg := NewAggregator(w).WithMaxBytes(16).WithFlushTimeout(0)
+
+		_, _ = agg.Write([]byte("first\n  second\n  third\n"))
+		assert.Nil(t, agg.Close())
+		w.Stop()
+
+		assert.Equal(t, "first\n  second\n  third\n", buff.String())
+		assert.Equal(t, uint64(2), w.Stats().EnqueuedRecords)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		defer w.Stop()
+		agg := NewAggregator(w).WithFlushTimeout(20 * time.Millisecond)
+		defer agg.Close()
+
+		_, _ = agg.Write([]byte("alone\n"))
+		assert.Equal(t, uint64(0), w.Stats().EnqueuedRecords)
+
+		assert.Eventually(t, func() bool { return w.Stats().EnqueuedRecords == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+		agg := NewAggregator(w)
+		assert.Nil(t, agg.Close())
+
+		_, err := agg.Write([]byte("late\n"))
+		assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+		w.Stop()
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+		agg := NewAggregator(w).WithFlushTimeout(0)
+
+		_, _ = agg.Write([]byte("first\n"))
+		w.Stop()
+
+		// 延续行被加入聚合记录，下一行触发入队失败，返回已处理的字节数
+		n, err := agg.Write([]byte("  cont\nsecond\nthird\n"))
+		assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+		assert.Equal(t, len("  cont\n"), n)
+	})
+}
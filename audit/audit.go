@@ -0,0 +1,154 @@
+// Package audit 提供防篡改的哈希链审计日志格式，以及校验审计文件的工具。
+//
+// Chain 为每条记录计算 HMAC-SHA256，输入为上一条记录的 MAC 和本条记录的内容，
+// 结果以 " audit=<64 位十六进制>" 的形式追加在记录末尾、换行之前：
+//
+//	user=alice action=login audit=5f0c...e1
+//
+// 修改、删除、插入或调换任意一条记录都会使之后的链接无法通过校验。
+// 记录内部的换行和回车（例如堆栈信息或合并后的记录）被转义为 "\n" 和 "\r"，反斜杠被转义为 "\\"，
+// 保证每条记录在文件中只占一行，并且包含字面 "\n" 的记录与包含真实换行的记录写出的行不同。
+// MAC 按转义后的内容计算，与 Verifier 按行读取的内容一致，Unescape 可以还原原始记录。
+// law.Config.WithAudit 把 Chain 作为最后一个转换阶段，在轮询器协程上按写出顺序计算，
+// 单一消费者保证了链的顺序就是记录在文件中的顺序。
+//
+// Verifier 按行校验文件并报告第一个断开的链接。进程重启后以追加方式继续写入同一个文件时，
+// 使用 Resume 从文件的最后一条记录继续计算链。
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+)
+
+// TagPrefix 追加在记录末尾的标签前缀
+const TagPrefix = " audit="
+
+// MACSize MAC 的长度
+const MACSize = sha256.Size
+
+// tagSize 标签的长度
+const tagSize = len(TagPrefix) + 2*MACSize
+
+// 错误定义
+var (
+	ErrorBrokenChain      = errors.New("audit: hash chain is broken")
+	ErrorMissingTag       = errors.New("audit: record has no audit tag")
+	ErrorIncompleteRecord = errors.New("audit: incomplete record at end of input")
+)
+
+// Chain 哈希链，记录上一条记录的 MAC，不能被多个协程并发使用，一个 Chain 只应配置给一个写入器
+type Chain struct {
+	mac     hash.Hash
+	prev    [MACSize]byte
+	sum     []byte
+	tag     [tagSize]byte
+	escaped []byte
+}
+
+// NewChain 创建使用 key 计算 HMAC-SHA256 的哈希链，第一条记录之前的 MAC 为全零
+func NewChain(key []byte) *Chain {
+	c := &Chain{mac: hmac.New(sha256.New, key)}
+	copy(c.tag[:], TagPrefix)
+	return c
+}
+
+// WithPrevious 设置第一条记录之前的 MAC，用于继续一条已有的链，长度不是 MACSize 时忽略
+func (c *Chain) WithPrevious(prev []byte) *Chain {
+	if len(prev) == MACSize {
+		copy(c.prev[:], prev)
+	}
+	return c
+}
+
+// Last 返回最后一条记录的 MAC
+func (c *Chain) Last() []byte {
+	return append([]byte(nil), c.prev[:]...)
+}
+
+// Transform 实现 law.Transform：计算记录的 MAC，并把带标签的记录写入 out。
+// 记录结尾的换行不参与计算，内部的换行、回车和反斜杠被转义，输出的记录总是只占一行并以换行结尾。
+func (c *Chain) Transform(in []byte, out *bytes.Buffer) (bool, error) {
+	record := trimNewline(in)
+	if bytes.ContainsAny(record, "\\\r\n") {
+		c.escaped = escapeRecord(c.escaped[:0], record)
+		record = c.escaped
+	}
+	c.next(record)
+
+	out.Grow(len(record) + tagSize + 1)
+	out.Write(record)
+	out.Write(c.tag[:])
+	out.WriteByte('\n')
+	return true, nil
+}
+
+// next 计算记录的 MAC 并更新链，标签写入 tag
+func (c *Chain) next(record []byte) {
+	c.sum = sumMAC(c.mac, c.prev[:], record, c.sum[:0])
+	copy(c.prev[:], c.sum)
+	hex.Encode(c.tag[len(TagPrefix):], c.sum)
+}
+
+// sumMAC 计算 HMAC(prev || record)，结果追加到 dst
+func sumMAC(mac hash.Hash, prev, record, dst []byte) []byte {
+	mac.Reset()
+	mac.Write(prev)
+	mac.Write(record)
+	return mac.Sum(dst)
+}
+
+// escapeRecord 将 record 中的换行、回车和反斜杠转义为 "\n"、"\r" 和 "\\" 后追加到 dst
+func escapeRecord(dst, record []byte) []byte {
+	for _, b := range record {
+		switch b {
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+// Unescape 还原 Chain 写出的记录内容（不包含标签和结尾的换行），将 "\n"、"\r" 和 "\\" 还原为换行、回车和反斜杠
+func Unescape(record []byte) []byte {
+	if bytes.IndexByte(record, '\\') < 0 {
+		return record
+	}
+
+	out := make([]byte, 0, len(record))
+	for i := 0; i < len(record); i++ {
+		b := record[i]
+		if b == '\\' && i+1 < len(record) {
+			switch record[i+1] {
+			case 'n':
+				b = '\n'
+				i++
+			case 'r':
+				b = '\r'
+				i++
+			case '\\':
+				i++
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// trimNewline 去掉结尾的换行
+func trimNewline(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		return b[:n-1]
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("audit-key")
+
+func chainLines(c *Chain, lines ...string) []byte {
+	var out bytes.Buffer
+	for _, l := range lines {
+		_, _ = c.Transform([]byte(l), &out)
+	}
+	return out.Bytes()
+}
+
+func TestVerifier_Valid(t *testing.T) {
+	c := NewChain(testKey)
+	data := chainLines(c, "alpha\n", "beta", "")
+
+	result, err := NewVerifier(testKey).Verify(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.False(t, result.Broken())
+	assert.Equal(t, 3, result.Records)
+	assert.Equal(t, int64(len(data)), result.ValidSize)
+	assert.Equal(t, c.Last(), result.Last)
+	assert.True(t, bytes.HasPrefix(data, []byte("alpha"+TagPrefix)))
+}
+
+func TestChain_Escape(t *testing.T) {
+	// 字面的 "\n" 与真实的换行写出不同的行
+	records := []string{"a\\nb", "a\nb", `c:\dir\`, "\r\\r"}
+	data := chainLines(NewChain(testKey), records...)
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	assert.Equal(t, len(records), len(lines))
+	assert.NotEqual(t, lines[0][:len(lines[0])-tagSize], lines[1][:len(lines[1])-tagSize])
+
+	for i, line := range lines {
+		assert.Equal(t, records[i], string(Unescape(line[:len(line)-tagSize])))
+	}
+
+	result, err := NewVerifier(testKey).Verify(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.False(t, result.Broken())
+	assert.Equal(t, len(records), result.Records)
+}
+
+func TestVerifier_Broken(t *testing.T) {
+	data := chainLines(NewChain(testKey), "one\n", "two\n", "three\n")
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	cases := map[string]struct {
+		data []byte
+		line int
+		err  error
+	}{
+		"modified": {bytes.Replace(data, []byte("two"), []byte("TWO"), 1), 2, ErrorBrokenChain},
+		"deleted":  {append(append([]byte(nil), lines[0]...), lines[2]...), 2, ErrorBrokenChain},
+		"inserted": {append(append(append([]byte(nil), lines[0]...), "forged\n"...), lines[1]...), 2, ErrorMissingTag},
+		"torn":     {data[:len(data)-1], 3, ErrorIncompleteRecord},
+	}
+	for name, tc := range cases {
+		result, err := NewVerifier(testKey).Verify(bytes.NewReader(tc.data))
+		assert.Nil(t, err, name)
+		assert.ErrorIs(t, result.Err, tc.err, name)
+		assert.Equal(t, tc.line, result.Line, name)
+		assert.Equal(t, tc.line-1, result.Records, name)
+	}
+
+	result, _ := NewVerifier([]byte("wrong-key")).Verify(bytes.NewReader(data))
+	assert.ErrorIs(t, result.Err, ErrorBrokenChain)
+	assert.Equal(t, 1, result.Line)
+}
+
+func TestResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	c, err := Resume(path, testKey)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(path, chainLines(c, "first\n"), 0o644))
+
+	c, err = Resume(path, testKey)
+	assert.Nil(t, err)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	assert.Nil(t, err)
+	_, _ = f.Write(chainLines(c, "second\n"))
+	assert.Nil(t, f.Close())
+
+	result, err := VerifyFile(path, testKey)
+	assert.Nil(t, err)
+	assert.False(t, result.Broken())
+	assert.Equal(t, 2, result.Records)
+
+	// 不从链尾继续的写入会使链断开
+	f, _ = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.Write(chainLines(NewChain(testKey), "restart\n"))
+	_ = f.Close()
+	_, err = Resume(path, testKey)
+	assert.ErrorIs(t, err, ErrorBrokenChain)
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// Result 校验结果
+type Result struct {
+	Records   int    // 通过校验的记录数
+	ValidSize int64  // 最后一条通过校验的记录的结束位置
+	Last      []byte // 最后一条通过校验的记录的 MAC，没有记录时为起始 MAC
+	Line      int    // 第一个断开的链接所在的行号（从 1 开始），链完整时为 0
+	Err       error  // 断开的原因，链完整时为 nil
+}
+
+// Broken 判断链是否断开
+func (r Result) Broken() bool {
+	return r.Err != nil
+}
+
+// Verifier 按行校验审计文件，每一行是一条记录，与 Chain 写出的格式一致。
+// 校验直接使用文件中转义后的行，不需要先还原，需要原始记录时对标签之前的内容调用 Unescape
+type Verifier struct {
+	mac  hash.Hash
+	prev [MACSize]byte
+}
+
+// NewVerifier 创建使用 key 校验的 Verifier，第一条记录之前的 MAC 为全零
+func NewVerifier(key []byte) *Verifier {
+	return &Verifier{mac: hmac.New(sha256.New, key)}
+}
+
+// WithPrevious 设置第一条记录之前的 MAC，用于校验从链中间开始的文件，长度不是 MACSize 时忽略
+func (v *Verifier) WithPrevious(prev []byte) *Verifier {
+	if len(prev) == MACSize {
+		copy(v.prev[:], prev)
+	}
+	return v
+}
+
+// Verify 依次校验 r 中的每一行，遇到第一个断开的链接时停止，原因记录在 Result.Err 中：
+// 缺少标签时为 ErrorMissingTag，MAC 不匹配时为 ErrorBrokenChain，最后一行没有换行时为 ErrorIncompleteRecord。
+// 读取 r 本身失败时返回错误。
+func (v *Verifier) Verify(r io.Reader) (Result, error) {
+	reader := bufio.NewReader(r)
+	prev := v.prev
+	result := Result{Last: prev[:]}
+
+	var (
+		sum  []byte
+		want [MACSize]byte
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && err == io.EOF {
+			return result, nil
+		}
+		if err != nil && err != io.EOF {
+			return result, err
+		}
+
+		result.Line = result.Records + 1
+		if err == io.EOF {
+			result.Err = ErrorIncompleteRecord
+			return result, nil
+		}
+
+		line = line[:len(line)-1]
+		if len(line) < tagSize || string(line[len(line)-tagSize:len(line)-2*MACSize]) != TagPrefix {
+			result.Err = ErrorMissingTag
+			return result, nil
+		}
+		record, tag := line[:len(line)-tagSize], line[len(line)-2*MACSize:]
+		if _, err = hex.Decode(want[:], tag); err != nil {
+			result.Err = ErrorMissingTag
+			return result, nil
+		}
+
+		sum = sumMAC(v.mac, prev[:], record, sum[:0])
+		if !hmac.Equal(sum, want[:]) {
+			result.Err = ErrorBrokenChain
+			return result, nil
+		}
+
+		copy(prev[:], sum)
+		result.Records++
+		result.ValidSize += int64(len(line) + 1)
+		result.Last = append(result.Last[:0:0], sum...)
+		result.Line = 0
+	}
+}
+
+// VerifyFile 使用 key 校验 path 指向的文件
+func VerifyFile(path string, key []byte) (Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+	return NewVerifier(key).Verify(f)
+}
+
+// Resume 校验 path 指向的文件，返回从最后一条记录继续的 Chain，用于以追加方式继续写入同一个文件。
+// 文件不存在时返回新的 Chain；链断开时返回 Result.Err，此时文件需要人工检查。
+func Resume(path string, key []byte) (*Chain, error) {
+	result, err := VerifyFile(path, key)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewChain(key), nil
+		}
+		return nil, err
+	}
+	if result.Broken() {
+		return nil, fmt.Errorf("%s: line %d: %w", path, result.Line, result.Err)
+	}
+	return NewChain(key).WithPrevious(result.Last), nil
+}
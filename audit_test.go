@@ -0,0 +1,63 @@
+package law
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shengyanli1982/law/audit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_Audit(t *testing.T) {
+	key := []byte("secret")
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().
+		WithAudit(audit.NewChain(key)).
+		WithTransforms(FilterTransform(func(r []byte) bool { return !bytes.HasPrefix(r, []byte("debug")) }))
+	w := NewWriteAsyncer(buff, conf)
+
+	for _, line := range []string{"login alice\n", "debug skipped\n", "logout alice"} {
+		_, _ = w.Write([]byte(line))
+	}
+	w.Stop()
+
+	// 审计链位于其他转换阶段之后，被过滤的记录不会进入链中
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasPrefix(lines[1], "logout alice"+audit.TagPrefix))
+
+	result, err := audit.NewVerifier(key).Verify(bytes.NewReader(buff.Bytes()))
+	assert.Nil(t, err)
+	assert.False(t, result.Broken())
+	assert.Equal(t, 2, result.Records)
+}
+
+func TestWriteAsyncer_AuditMultiline(t *testing.T) {
+	key := []byte("secret")
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+
+	w := NewWriteAsyncer(f, NewConfig().WithAudit(audit.NewChain(key)))
+	for _, record := range []string{"panic: boom\n\tgoroutine 1\n", "ok\n", "crlf\r\nline\n"} {
+		_, _ = w.Write([]byte(record))
+	}
+	w.Stop()
+	assert.Nil(t, f.Close())
+
+	// 内部的换行被转义，每条记录只占一行
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.HasPrefix(lines[0], "panic: boom\\n\tgoroutine 1"+audit.TagPrefix))
+	assert.True(t, strings.HasPrefix(lines[2], `crlf\r\nline`+audit.TagPrefix))
+
+	result, err := audit.VerifyFile(path, key)
+	assert.Nil(t, err)
+	assert.False(t, result.Broken())
+	assert.Equal(t, 3, result.Records)
+}
@@ -5,6 +5,7 @@ import (
 
 	x "github.com/shengyanli1982/law"
 	xu "github.com/shengyanli1982/law/internal/utils"
+	"github.com/shengyanli1982/law/redact"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -131,3 +132,60 @@ func BenchmarkZapAsyncWriterParallel(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkLogAsyncWriterOwned(b *testing.B) {
+	w := xu.BlackHoleWriter{}
+
+	aw := x.NewWriteAsyncer(&w, nil)
+	defer aw.Stop()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buff := aw.AcquireBuffer()
+		buff.WriteString("hello")
+		_, _ = aw.WriteOwned(buff)
+	}
+}
+
+var redactRecord = []byte("2024-05-01T10:00:01Z WARN login failed user=alice@example.com token=Bearer abc.def\n")
+
+func BenchmarkLogAsyncWriterRedact(b *testing.B) {
+	w := xu.BlackHoleWriter{}
+
+	aw := x.NewWriteAsyncer(&w, x.NewConfig().WithTransforms(redact.New().Transform))
+	defer aw.Stop()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = aw.Write(redactRecord)
+	}
+}
+
+// BenchmarkLogAsyncWriterDrain 统计包含轮询器写出在内的端到端吞吐，作为脱敏基准的对照
+func BenchmarkLogAsyncWriterDrain(b *testing.B) {
+	w := xu.BlackHoleWriter{}
+
+	aw := x.NewWriteAsyncer(&w, nil)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = aw.Write(redactRecord)
+	}
+	aw.Stop()
+}
+
+func BenchmarkLogAsyncWriterRedactDrain(b *testing.B) {
+	w := xu.BlackHoleWriter{}
+
+	aw := x.NewWriteAsyncer(&w, x.NewConfig().WithTransforms(redact.New().Transform))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = aw.Write(redactRecord)
+	}
+	aw.Stop()
+}
@@ -0,0 +1,57 @@
+package law
+
+import (
+	wr "github.com/shengyanli1982/law/internal/writer"
+)
+
+// DefaultSizeClasses 返回默认的缓冲区大小类别：128B、1KB、8KB、32KB
+func DefaultSizeClasses() []int {
+	return wr.DefaultSizeClasses()
+}
+
+// BufferPool 是按大小类别复用缓冲区的缓冲池，可以通过 WithBufferPool 在多个写入器之间共享。
+//
+// 大小超过最大类别的记录使用单独分配的缓冲区，写出后不会被缓冲池保留。
+// 设置了保留内存预算时，缓冲池中空闲缓冲区的容量之和不超过预算，超出部分交给 GC 回收。
+type BufferPool struct {
+	pool *wr.BufferPool
+}
+
+// NewBufferPool 创建新的缓冲池。
+// sizeClasses 为各类别的缓冲区容量，会被去重并升序排列，为空时使用 DefaultSizeClasses；
+// budget 为空闲缓冲区保留内存的上限（字节），小于等于 0 表示不限。
+func NewBufferPool(sizeClasses []int, budget int64) *BufferPool {
+	return &BufferPool{pool: wr.NewBufferPoolWithClasses(sizeClasses, budget)}
+}
+
+// BufferClassStats 单个大小类别的统计信息
+type BufferClassStats struct {
+	Size   int    // 类别的缓冲区容量
+	Gets   uint64 // 从该类别获取缓冲区的次数
+	Misses uint64 // 该类别没有空闲缓冲区、需要新分配的次数
+}
+
+// BufferPoolStats 缓冲池统计信息
+type BufferPoolStats struct {
+	Classes  []BufferClassStats // 按容量升序排列的各类别统计
+	Oversize uint64             // 超过最大类别、单独分配缓冲区的次数
+	Dropped  uint64             // 归还时因超出预算或容量过大而未被保留的次数
+	Retained int64              // 空闲缓冲区占用的字节数，仅在设置预算时统计
+	Budget   int64              // 保留内存预算，0 表示不限
+}
+
+// Stats 返回缓冲池的统计信息，共享的缓冲池统计的是所有写入器的总和
+func (p *BufferPool) Stats() BufferPoolStats {
+	raw := p.pool.Stats()
+	stats := BufferPoolStats{
+		Classes:  make([]BufferClassStats, 0, len(raw.Classes)),
+		Oversize: uint64(raw.Oversize),
+		Dropped:  uint64(raw.Dropped),
+		Retained: raw.Retained,
+		Budget:   raw.Budget,
+	}
+	for _, c := range raw.Classes {
+		stats.Classes = append(stats.Classes, BufferClassStats{Size: c.Size, Gets: uint64(c.Gets), Misses: uint64(c.Misses)})
+	}
+	return stats
+}
@@ -0,0 +1,79 @@
+package law
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferPool_SizeClasses(t *testing.T) {
+	pool := NewBufferPool([]int{1024, 256, 0, 1024, 64 * 1024}, 0)
+
+	stats := pool.Stats()
+	assert.Len(t, stats.Classes, 3)
+	assert.Equal(t, 256, stats.Classes[0].Size)
+	assert.Equal(t, 1024, stats.Classes[1].Size)
+	assert.Equal(t, 64*1024, stats.Classes[2].Size)
+
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithBufferPool(pool))
+	_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+	_, _ = w.Write([]byte(strings.Repeat("b", 600)))
+	_, _ = w.Write([]byte(strings.Repeat("c", 40*1024)))
+	_, _ = w.Write([]byte(strings.Repeat("d", 100*1024)))
+	w.Stop()
+
+	stats = w.Stats().BufferPool
+	assert.Equal(t, uint64(1), stats.Classes[0].Gets)
+	assert.Equal(t, uint64(1), stats.Classes[1].Gets)
+	assert.Equal(t, uint64(1), stats.Classes[2].Gets)
+	assert.Equal(t, uint64(1), stats.Oversize)
+	assert.Equal(t, 100+600+40*1024+100*1024, buff.Len())
+}
+
+func TestBufferPool_Budget(t *testing.T) {
+	pool := NewBufferPool([]int{1024}, 2048)
+
+	buffs := make([]*bytes.Buffer, 0, 4)
+	for i := 0; i < 4; i++ {
+		buffs = append(buffs, pool.pool.GetWithHint(512))
+	}
+	for _, b := range buffs {
+		pool.pool.Put(b)
+	}
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(2048), stats.Retained)
+	assert.Equal(t, int64(2048), stats.Budget)
+	assert.Equal(t, uint64(2), stats.Dropped)
+	assert.Equal(t, uint64(4), stats.Classes[0].Misses)
+
+	_ = pool.pool.Get()
+	stats = pool.Stats()
+	assert.Equal(t, int64(1024), stats.Retained)
+	assert.Equal(t, uint64(5), stats.Classes[0].Gets)
+	assert.Equal(t, uint64(4), stats.Classes[0].Misses)
+}
+
+func TestBufferPool_Shared(t *testing.T) {
+	pool := NewBufferPool(nil, 0)
+	for i, size := range DefaultSizeClasses() {
+		assert.Equal(t, size, pool.Stats().Classes[i].Size)
+	}
+
+	b1 := bytes.NewBuffer(make([]byte, 0, 1024))
+	b2 := bytes.NewBuffer(make([]byte, 0, 1024))
+	w1 := NewWriteAsyncer(b1, NewConfig().WithBufferPool(pool))
+	w2 := NewWriteAsyncer(b2, NewConfig().WithBufferPool(pool))
+	_, _ = w1.Write([]byte("hello"))
+	_, _ = w2.Write([]byte("world"))
+	w1.Stop()
+	w2.Stop()
+
+	assert.Equal(t, "hello", b1.String())
+	assert.Equal(t, "world", b2.String())
+	assert.Equal(t, uint64(2), pool.Stats().Classes[0].Gets)
+	assert.Equal(t, w1.Stats().BufferPool, w2.Stats().BufferPool)
+}
@@ -0,0 +1,136 @@
+// Command lawdecrypt 解密 law 加密写入的文件，并把明文写到标准输出。
+//
+// 用法：
+//
+//	lawdecrypt -key 1:<hex> [-key 2:<hex>] [-keyfile keys.txt] [file ...]
+//
+// 没有指定文件时读取标准输入。keyfile 每行一个 "<id> <hex>"，空行和以 # 开头的行会被忽略。
+// 所有已通过认证的明文都会被输出；发现被截断的流时退出码为 2，其他错误的退出码为 1。
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shengyanli1982/law/crypt"
+)
+
+// keyFlags 可重复的 -key 参数
+type keyFlags []string
+
+func (k *keyFlags) String() string {
+	return strings.Join(*k, ",")
+}
+
+func (k *keyFlags) Set(value string) error {
+	*k = append(*k, value)
+	return nil
+}
+
+func main() {
+	var keys keyFlags
+	flag.Var(&keys, "key", "decryption key as <id>:<hex>, may be repeated")
+	keyFile := flag.String("keyfile", "", "file with one \"<id> <hex>\" key per line")
+	flag.Parse()
+
+	ring := crypt.NewKeyring()
+	for _, k := range keys {
+		id, secret, ok := strings.Cut(k, ":")
+		if !ok {
+			fatal(fmt.Errorf("invalid key %q, expected <id>:<hex>", k))
+		}
+		if err := addKey(ring, id, secret); err != nil {
+			fatal(err)
+		}
+	}
+	if *keyFile != "" {
+		if err := loadKeyFile(ring, *keyFile); err != nil {
+			fatal(err)
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	truncated := false
+	decrypt := func(name string, r io.Reader) {
+		_, err := crypt.Decrypt(out, r, ring.Lookup)
+		switch {
+		case errors.Is(err, crypt.ErrorTruncated):
+			fmt.Fprintf(os.Stderr, "lawdecrypt: %s: %v\n", name, err)
+			truncated = true
+		case err != nil:
+			_ = out.Flush()
+			fatal(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if flag.NArg() == 0 {
+		decrypt("stdin", os.Stdin)
+	}
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			_ = out.Flush()
+			fatal(err)
+		}
+		decrypt(path, f)
+		_ = f.Close()
+	}
+
+	if err := out.Flush(); err != nil {
+		fatal(err)
+	}
+	if truncated {
+		os.Exit(2)
+	}
+}
+
+// addKey 解析并添加一个密钥
+func addKey(ring *crypt.Keyring, id, secret string) error {
+	n, err := strconv.ParseUint(strings.TrimSpace(id), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid key id %q: %w", id, err)
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(secret))
+	if err != nil {
+		return fmt.Errorf("invalid key %d: %w", n, err)
+	}
+	if err = ring.Add(uint32(n), b); err != nil {
+		return fmt.Errorf("invalid key %d: %w", n, err)
+	}
+	return nil
+}
+
+// loadKeyFile 读取密钥文件
+func loadKeyFile(ring *crypt.Keyring, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: expected \"<id> <hex>\"", path, i+1)
+		}
+		if err = addKey(ring, fields[0], fields[1]); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+	}
+	return nil
+}
+
+// fatal 打印错误并退出
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "lawdecrypt: %v\n", err)
+	os.Exit(1)
+}
@@ -1,10 +1,12 @@
 package law
 
 import (
-	"bytes"
 	"time"
 
-	iq "github.com/shengyanli1982/law/internal/queue"
+	"github.com/shengyanli1982/law/audit"
+	"github.com/shengyanli1982/law/crypt"
+	"github.com/shengyanli1982/law/frame"
+	wr "github.com/shengyanli1982/law/internal/writer"
 )
 
 // DefaultBufferSize 默认缓冲区大小
@@ -23,6 +25,23 @@ type Config struct {
 	queue             Queue         // 队列实现
 	heartbeatInterval time.Duration // 心跳间隔
 	idleTimeout       time.Duration // 闲置超时
+	latencyStats      bool          // 是否统计延迟
+	sizeClasses       []int         // 缓冲池大小类别
+	poolBudget        int64         // 缓冲池保留内存预算
+	bufferPool        *BufferPool   // 共享的缓冲池
+	maxRecordSize     int           // 单条记录的最大长度
+	oversizeMode      OversizeMode  // 超长记录的处理方式
+	ensureNewline     bool          // 是否为记录补齐结尾的换行
+	framing           frame.Flags   // 二进制分帧选项
+	frameSeqStart     uint64        // 第一帧的序号
+	transforms        []Transform   // 记录转换阶段
+	sampler           *Sampler      // 采样器
+	dedupWindow       time.Duration // 连续重复记录的合并窗口
+	encryptionKeys    crypt.KeyFunc // 静态加密的密钥
+	audit             *audit.Chain  // 审计哈希链
+	closeReplaced     bool          // 替换底层 io.Writer 时是否关闭原来的 io.Writer
+	name              string        // 写入器在注册表中的名称
+	pausedStop        StopPolicy    // 暂停期间停止时对队列中记录的处理方式
 }
 
 // NewConfig 创建新的配置实例
@@ -30,7 +49,6 @@ func NewConfig() *Config {
 	return &Config{
 		buffSize:          DefaultBufferSize,
 		callback:          newEmptyCallback(),
-		queue:             iq.NewMPSCQueue[*bytes.Buffer](),
 		heartbeatInterval: DefaultHeartbeatInterval,
 		idleTimeout:       DefaultIdleTimeout,
 	}
@@ -53,7 +71,8 @@ func (c *Config) WithCallback(cb Callback) *Config {
 	return c
 }
 
-// WithQueue 设置队列实现
+// WithQueue 设置队列实现，为 nil 时使用内置的无界 MPSC 队列。
+// 自定义队列只能承载 *bytes.Buffer，因此不会统计入队延迟。
 func (c *Config) WithQueue(q Queue) *Config {
 	c.queue = q
 	return c
@@ -71,6 +90,229 @@ func (c *Config) WithIdleTimeout(timeout time.Duration) *Config {
 	return c
 }
 
+// WithLatencyStats 设置是否统计入队到写出的延迟和刷新耗时。
+// 开启后每次 Write 都会记录一次时间戳，默认关闭。
+func (c *Config) WithLatencyStats(enabled bool) *Config {
+	c.latencyStats = enabled
+	return c
+}
+
+// WithBufferSizeClasses 设置缓冲池的大小类别，为空时使用 DefaultSizeClasses。
+// 通过 WithBufferPool 设置了共享缓冲池时该设置无效。
+func (c *Config) WithBufferSizeClasses(classes ...int) *Config {
+	c.sizeClasses = append([]int(nil), classes...)
+	return c
+}
+
+// WithBufferPoolBudget 设置缓冲池空闲缓冲区保留内存的上限（字节），小于等于 0 表示不限。
+// 通过 WithBufferPool 设置了共享缓冲池时该设置无效。
+func (c *Config) WithBufferPoolBudget(budget int64) *Config {
+	c.poolBudget = budget
+	return c
+}
+
+// WithBufferPool 设置共享的缓冲池，为 nil 时每个写入器按大小类别和预算创建自己的缓冲池
+func (c *Config) WithBufferPool(pool *BufferPool) *Config {
+	c.bufferPool = pool
+	return c
+}
+
+// WithMaxRecordSize 设置单条记录的最大长度（字节）和超长记录的处理方式，size <= 0 表示不限制。
+// 拆分模式下最大长度至少为 64 字节，较小的值会被调整为 64。
+func (c *Config) WithMaxRecordSize(size int, mode OversizeMode) *Config {
+	c.maxRecordSize = size
+	c.oversizeMode = mode
+	return c
+}
+
+// WithEnsureNewline 设置是否为不以换行结尾的记录追加换行，默认关闭。
+// 追加的换行计入记录长度，参与 WithMaxRecordSize 的判断。
+func (c *Config) WithEnsureNewline(enabled bool) *Config {
+	c.ensureNewline = enabled
+	return c
+}
+
+// WithFraming 设置二进制分帧选项，为 0 时不分帧（默认）。
+// 开启后每条记录都以 frame 包定义的格式写出，可以用 frame.Reader 读取。
+func (c *Config) WithFraming(flags frame.Flags) *Config {
+	c.framing = flags
+	return c
+}
+
+// WithFrameSequenceStart 设置第一帧的序号，用于在已有的分帧文件后继续编号
+func (c *Config) WithFrameSequenceStart(seq uint64) *Config {
+	c.frameSeqStart = seq
+	return c
+}
+
+// WithTransforms 在已有的转换阶段之后追加转换阶段，记录按注册顺序依次经过每个阶段。
+// 转换在轮询器协程上执行，位于 WithEnsureNewline、WithMaxRecordSize 之后和 WithFraming 之前。
+func (c *Config) WithTransforms(stages ...Transform) *Config {
+	for _, stage := range stages {
+		if stage != nil {
+			c.transforms = append(c.transforms, stage)
+		}
+	}
+	return c
+}
+
+// WithSampler 设置采样器，为 nil 时保留所有记录（默认）
+func (c *Config) WithSampler(s *Sampler) *Config {
+	c.sampler = s
+	return c
+}
+
+// WithDedup 设置连续重复记录的合并窗口，为 0 时不合并（默认）。
+// 轮询器按哈希比较相邻的记录，一轮连续重复的记录只写出第一条，其余计入 Stats().RepeatedRecords；
+// 出现不同的记录、一轮持续超过 window、闲置刷新、Flush 或 Stop 时写出 "last message repeated N times" 摘要。
+func (c *Config) WithDedup(window time.Duration) *Config {
+	c.dedupWindow = window
+	return c
+}
+
+// WithEncryption 开启静态加密，keys 为 nil 时不加密（默认）。
+// 输出以 AES-GCM 块写入底层 io.Writer，每次刷新缓冲写入器产生一个块，Stop 时写出结束块。
+// keys 在写入每个块时于轮询器协程上调用，返回新的密钥即可轮换；加密文件通过 crypt.NewReader 或 lawdecrypt 命令读取。
+func (c *Config) WithEncryption(keys crypt.KeyFunc) *Config {
+	c.encryptionKeys = keys
+	return c
+}
+
+// WithAudit 开启哈希链审计模式，chain 为 nil 时关闭（默认）。
+// chain 作为最后一个转换阶段在轮询器协程上执行，为每条记录追加与上一条记录链接的 HMAC-SHA256 标签，
+// 包括重复摘要和采样摘要在内的所有记录都会进入链中。审计文件通过 audit.Verifier 校验。
+func (c *Config) WithAudit(chain *audit.Chain) *Config {
+	c.audit = chain
+	return c
+}
+
+// WithCloseReplacedWriter 设置 SetWriter 替换底层 io.Writer 后是否关闭原来的 io.Writer（需要实现 io.Closer），默认不关闭
+func (c *Config) WithCloseReplacedWriter(close bool) *Config {
+	c.closeReplaced = close
+	return c
+}
+
+// WithName 设置写入器的名称，非空时写入器创建后自动加入进程级注册表，可以通过 Lookup 查找，
+// 并参与 FlushAll 和 StopAll，Stop 时自动移除
+func (c *Config) WithName(name string) *Config {
+	c.name = name
+	return c
+}
+
+// WithPausedStopPolicy 设置暂停期间调用 Stop 时对队列中记录的处理方式，默认为 StopDrain
+func (c *Config) WithPausedStopPolicy(policy StopPolicy) *Config {
+	c.pausedStop = policy
+	return c
+}
+
+// BufferSize 返回缓冲区大小
+func (c *Config) BufferSize() int {
+	return c.buffSize
+}
+
+// HeartbeatInterval 返回心跳间隔
+func (c *Config) HeartbeatInterval() time.Duration {
+	return c.heartbeatInterval
+}
+
+// IdleTimeout 返回闲置超时时间
+func (c *Config) IdleTimeout() time.Duration {
+	return c.idleTimeout
+}
+
+// LatencyStatsEnabled 返回是否统计延迟
+func (c *Config) LatencyStatsEnabled() bool {
+	return c.latencyStats
+}
+
+// BufferSizeClasses 返回缓冲池的大小类别
+func (c *Config) BufferSizeClasses() []int {
+	return append([]int(nil), c.sizeClasses...)
+}
+
+// BufferPoolBudget 返回缓冲池保留内存的上限
+func (c *Config) BufferPoolBudget() int64 {
+	return c.poolBudget
+}
+
+// BufferPool 返回共享的缓冲池，未设置时返回 nil
+func (c *Config) BufferPool() *BufferPool {
+	return c.bufferPool
+}
+
+// MaxRecordSize 返回单条记录的最大长度，0 表示不限制
+func (c *Config) MaxRecordSize() int {
+	return c.maxRecordSize
+}
+
+// OversizeMode 返回超长记录的处理方式
+func (c *Config) OversizeMode() OversizeMode {
+	return c.oversizeMode
+}
+
+// EnsureNewline 返回是否为记录补齐结尾的换行
+func (c *Config) EnsureNewline() bool {
+	return c.ensureNewline
+}
+
+// Framing 返回二进制分帧选项
+func (c *Config) Framing() frame.Flags {
+	return c.framing
+}
+
+// FrameSequenceStart 返回第一帧的序号
+func (c *Config) FrameSequenceStart() uint64 {
+	return c.frameSeqStart
+}
+
+// Transforms 返回记录转换阶段
+func (c *Config) Transforms() []Transform {
+	return append([]Transform(nil), c.transforms...)
+}
+
+// Sampler 返回采样器，未设置时返回 nil
+func (c *Config) Sampler() *Sampler {
+	return c.sampler
+}
+
+// DedupWindow 返回连续重复记录的合并窗口
+func (c *Config) DedupWindow() time.Duration {
+	return c.dedupWindow
+}
+
+// EncryptionKeys 返回静态加密的密钥函数，未开启加密时返回 nil
+func (c *Config) EncryptionKeys() crypt.KeyFunc {
+	return c.encryptionKeys
+}
+
+// Audit 返回审计哈希链，未开启时返回 nil
+func (c *Config) Audit() *audit.Chain {
+	return c.audit
+}
+
+// CloseReplacedWriter 返回 SetWriter 替换底层 io.Writer 后是否关闭原来的 io.Writer
+func (c *Config) CloseReplacedWriter() bool {
+	return c.closeReplaced
+}
+
+// Name 返回写入器的名称
+func (c *Config) Name() string {
+	return c.name
+}
+
+// PausedStopPolicy 返回暂停期间调用 Stop 时对队列中记录的处理方式
+func (c *Config) PausedStopPolicy() StopPolicy {
+	return c.pausedStop
+}
+
+// clone 返回配置的浅拷贝
+func (c *Config) clone() *Config {
+	copied := *c
+	copied.sizeClasses = c.BufferSizeClasses()
+	copied.transforms = c.Transforms()
+	return &copied
+}
+
 // isConfigValid 验证并修正配置
 func isConfigValid(conf *Config) *Config {
 	if conf != nil {
@@ -80,17 +322,38 @@ func isConfigValid(conf *Config) *Config {
 		if conf.callback == nil {
 			conf.callback = newEmptyCallback()
 		}
-		if conf.queue == nil {
-			conf.queue = iq.NewMPSCQueue[*bytes.Buffer]()
-		}
 		if conf.heartbeatInterval <= 0 {
 			conf.heartbeatInterval = DefaultHeartbeatInterval
 		}
 		if conf.idleTimeout <= 0 {
 			conf.idleTimeout = DefaultIdleTimeout
 		}
+		if len(conf.sizeClasses) == 0 {
+			conf.sizeClasses = DefaultSizeClasses()
+		} else {
+			conf.sizeClasses = wr.NormalizeSizeClasses(conf.sizeClasses)
+		}
+		if conf.poolBudget < 0 {
+			conf.poolBudget = 0
+		}
+		conf.framing = conf.framing.Normalize()
+		if conf.maxRecordSize < 0 {
+			conf.maxRecordSize = 0
+		}
+		if conf.pausedStop != StopDrain && conf.pausedStop != StopDiscard {
+			conf.pausedStop = StopDrain
+		}
+		if conf.dedupWindow < 0 {
+			conf.dedupWindow = 0
+		}
+		if conf.oversizeMode < OversizeReject || conf.oversizeMode > OversizeSplit {
+			conf.oversizeMode = OversizeReject
+		}
+		if conf.oversizeMode == OversizeSplit && conf.maxRecordSize > 0 && conf.maxRecordSize < wr.MinSplitSize {
+			conf.maxRecordSize = wr.MinSplitSize
+		}
 	} else {
-		conf = DefaultConfig()
+		conf = isConfigValid(DefaultConfig())
 	}
 	return conf
 }
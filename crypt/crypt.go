@@ -0,0 +1,281 @@
+// Package crypt 提供 law 输出流的静态加密格式，以及读取加密文件的工具。
+//
+// Writer 包装底层 io.Writer，把每次 Write 的内容加密为一个经过认证的块（AES-GCM）。
+// law.Config.WithEncryption 把 Writer 放在缓冲写入器之下，因此块的边界与缓冲写入器的刷新对齐。
+//
+// 一个流的格式如下：
+//
+//	stream header: "LAWE" | version (1 字节) | stream id (16 字节)
+//	chunk:         type (1 字节) | key id (uint32) | sequence (uint64) | length (uint32) | nonce (12 字节) | ciphertext
+//
+// 整数均为大端序，length 为 ciphertext（包含 16 字节认证标签）的长度。
+// 附加认证数据包含 stream id 和 nonce 之前的块头，因此块不能被篡改、调换顺序或移动到其他流中。
+// 每个流以一个不含数据的结束块结尾，读取时缺少结束块的流会被报告为 ErrorTruncated，而不是被当作完整的流接受。
+// 同一个文件中可以依次追加多个流，例如进程重启后以追加方式重新打开文件。
+//
+// 密钥通过 KeyFunc 在写入每个块时获取，返回新的密钥即可轮换，块头记录密钥编号，读取时通过 KeyLookup 按编号查找密钥。
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// 流头
+const (
+	streamMagic      = "LAWE"
+	streamVersion    = 1
+	streamIDSize     = 16
+	streamHeaderSize = len(streamMagic) + 1 + streamIDSize
+)
+
+// 块类型
+const (
+	chunkData  = 0x01
+	chunkFinal = 0x02
+)
+
+// 块头
+const (
+	nonceSize       = 12
+	tagSize         = 16
+	chunkAADSize    = 1 + 4 + 8 + 4
+	chunkHeaderSize = chunkAADSize + nonceSize
+)
+
+// DefaultMaxChunkSize 读取时允许的默认最大块长度
+const DefaultMaxChunkSize = 64 * 1024 * 1024
+
+// 错误定义
+var (
+	ErrorTruncated      = errors.New("crypt: stream is truncated")
+	ErrorAuthentication = errors.New("crypt: chunk authentication failed")
+	ErrorInvalidFormat  = errors.New("crypt: invalid stream format")
+	ErrorChunkTooLarge  = errors.New("crypt: chunk exceeds maximum size")
+	ErrorUnknownKey     = errors.New("crypt: unknown key id")
+	ErrorNoCurrentKey   = errors.New("crypt: no current key")
+	ErrorInvalidKey     = errors.New("crypt: key must be 16, 24 or 32 bytes")
+	ErrorWriterClosed   = errors.New("crypt: writer is closed")
+)
+
+// Key 加密密钥，Secret 为 16、24 或 32 字节的 AES 密钥
+type Key struct {
+	ID     uint32
+	Secret []byte
+}
+
+// KeyFunc 返回加密下一个块使用的密钥，在写入每个块时调用
+type KeyFunc func() (Key, error)
+
+// KeyLookup 按编号返回解密使用的密钥，找不到时应返回 ErrorUnknownKey
+type KeyLookup func(id uint32) ([]byte, error)
+
+// StaticKey 返回始终使用同一个密钥的 KeyFunc
+func StaticKey(id uint32, secret []byte) KeyFunc {
+	return func() (Key, error) {
+		return Key{ID: id, Secret: secret}, nil
+	}
+}
+
+// Keyring 密钥环，保存当前密钥和历史密钥，可以被多个协程并发使用。
+// Keyring.Current 可以作为 KeyFunc，Keyring.Lookup 可以作为 KeyLookup。
+type Keyring struct {
+	mu         sync.RWMutex
+	keys       map[uint32][]byte
+	current    uint32
+	hasCurrent bool
+}
+
+// NewKeyring 创建空的密钥环
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[uint32][]byte)}
+}
+
+// Add 添加只用于解密的密钥
+func (k *Keyring) Add(id uint32, secret []byte) error {
+	if !validKeySize(len(secret)) {
+		return ErrorInvalidKey
+	}
+	k.mu.Lock()
+	k.keys[id] = append([]byte(nil), secret...)
+	k.mu.Unlock()
+	return nil
+}
+
+// Rotate 添加密钥并将其设为当前密钥，之后写入的块使用该密钥
+func (k *Keyring) Rotate(id uint32, secret []byte) error {
+	if err := k.Add(id, secret); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	k.current, k.hasCurrent = id, true
+	k.mu.Unlock()
+	return nil
+}
+
+// Current 返回当前密钥，尚未设置时返回 ErrorNoCurrentKey
+func (k *Keyring) Current() (Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if !k.hasCurrent {
+		return Key{}, ErrorNoCurrentKey
+	}
+	return Key{ID: k.current, Secret: k.keys[k.current]}, nil
+}
+
+// Lookup 按编号返回密钥
+func (k *Keyring) Lookup(id uint32) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	secret, ok := k.keys[id]
+	if !ok {
+		return nil, ErrorUnknownKey
+	}
+	return secret, nil
+}
+
+// validKeySize 判断密钥长度是否合法
+func validKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+// newAEAD 创建 AES-GCM 实例
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	if !validKeySize(len(secret)) {
+		return nil, ErrorInvalidKey
+	}
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Writer 加密写入器，每次 Write 写出一个加密块，不能被多个协程并发使用。
+// 写入底层 io.Writer 失败后，流中可能留下不完整的块，之后的 Write 和 Close 都返回同一个错误，不再写出任何内容。
+type Writer struct {
+	w        io.Writer
+	keys     KeyFunc
+	streamID [streamIDSize]byte
+	seq      uint64
+	started  bool
+	closed   bool
+	err      error
+	keyID    uint32
+	secret   []byte
+	aead     cipher.AEAD
+	buf      []byte
+	aad      []byte
+}
+
+// NewWriter 创建写入 w 的加密写入器，keys 在写入每个块时调用
+func NewWriter(w io.Writer, keys KeyFunc) *Writer {
+	return &Writer{w: w, keys: keys}
+}
+
+// Write 将 p 加密为一个块写入底层 io.Writer。第一个块之前会写出流头。
+func (e *Writer) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, ErrorWriterClosed
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := e.writeChunk(chunkData, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close 写出结束块，不会关闭底层 io.Writer。没有写入过任何块时不写出任何内容。
+func (e *Writer) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+	if !e.started {
+		return nil
+	}
+	return e.writeChunk(chunkFinal, nil)
+}
+
+// writeChunk 加密并写出一个块
+func (e *Writer) writeChunk(kind byte, plaintext []byte) error {
+	aead, keyID, err := e.cipher()
+	if err != nil {
+		return err
+	}
+
+	e.buf = e.buf[:0]
+	if !e.started {
+		if _, err = io.ReadFull(rand.Reader, e.streamID[:]); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, streamMagic...)
+		e.buf = append(e.buf, streamVersion)
+		e.buf = append(e.buf, e.streamID[:]...)
+	}
+
+	start := len(e.buf)
+	e.buf = append(e.buf, kind)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, keyID)
+	e.buf = binary.BigEndian.AppendUint64(e.buf, e.seq)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(len(plaintext)+tagSize))
+	aadEnd := len(e.buf)
+	e.buf = append(e.buf, make([]byte, nonceSize)...)
+	nonce := e.buf[aadEnd:]
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	e.aad = appendAAD(e.aad[:0], e.streamID[:], e.buf[start:aadEnd])
+	e.buf = aead.Seal(e.buf, nonce, plaintext, e.aad)
+
+	// 调用底层 io.Writer 之后流头可能已经写出，即使写入失败也不能再写出新的流头
+	e.started = true
+	n, err := e.w.Write(e.buf)
+	if err == nil && n < len(e.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		e.err = err
+		return err
+	}
+	e.seq++
+	return nil
+}
+
+// cipher 返回当前密钥对应的 AES-GCM 实例，密钥未变化时复用上一个实例
+func (e *Writer) cipher() (cipher.AEAD, uint32, error) {
+	key, err := e.keys()
+	if err != nil {
+		return nil, 0, err
+	}
+	if e.aead != nil && key.ID == e.keyID && string(key.Secret) == string(e.secret) {
+		return e.aead, e.keyID, nil
+	}
+
+	aead, err := newAEAD(key.Secret)
+	if err != nil {
+		return nil, 0, err
+	}
+	e.aead, e.keyID, e.secret = aead, key.ID, append(e.secret[:0], key.Secret...)
+	return aead, key.ID, nil
+}
+
+// appendAAD 追加块的附加认证数据
+func appendAAD(dst, streamID, header []byte) []byte {
+	dst = append(dst, streamID...)
+	return append(dst, header...)
+}
@@ -0,0 +1,136 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func encryptChunks(keys KeyFunc, chunks ...string) []byte {
+	var out bytes.Buffer
+	w := NewWriter(&out, keys)
+	for _, c := range chunks {
+		_, _ = w.Write([]byte(c))
+	}
+	_ = w.Close()
+	return out.Bytes()
+}
+
+func TestReader_RoundTrip(t *testing.T) {
+	ring := NewKeyring()
+	assert.Nil(t, ring.Rotate(1, key1))
+
+	var out bytes.Buffer
+	w := NewWriter(&out, ring.Current)
+	_, _ = w.Write([]byte("alpha\n"))
+
+	// 轮换后的块使用新密钥，旧密钥仍可用于解密
+	assert.Nil(t, ring.Rotate(2, key2))
+	_, _ = w.Write([]byte("beta\n"))
+	assert.Nil(t, w.Close())
+	assert.False(t, bytes.Contains(out.Bytes(), []byte("alpha")))
+
+	plain, err := io.ReadAll(NewReader(bytes.NewReader(out.Bytes()), ring.Lookup))
+	assert.Nil(t, err)
+	assert.Equal(t, "alpha\nbeta\n", string(plain))
+
+	// 同一个文件中追加的第二个流
+	data := append(out.Bytes(), encryptChunks(ring.Current, "gamma\n")...)
+	plain, err = io.ReadAll(NewReader(bytes.NewReader(data), ring.Lookup))
+	assert.Nil(t, err)
+	assert.Equal(t, "alpha\nbeta\ngamma\n", string(plain))
+}
+
+func TestReader_Truncated(t *testing.T) {
+	keys := StaticKey(1, key1)
+	lookup := func(uint32) ([]byte, error) { return key1, nil }
+	data := encryptChunks(keys, "first\n", "second\n")
+
+	t.Run("any cut", func(t *testing.T) {
+		for cut := 1; cut < len(data); cut++ {
+			_, err := io.ReadAll(NewReader(bytes.NewReader(data[:cut]), lookup))
+			assert.ErrorIs(t, err, ErrorTruncated, "cut at %d", cut)
+		}
+	})
+
+	t.Run("followed by new stream", func(t *testing.T) {
+		var out bytes.Buffer
+		w := NewWriter(&out, keys)
+		_, _ = w.Write([]byte("lost tail\n"))
+		crashed := append(out.Bytes(), data...)
+
+		var plain bytes.Buffer
+		_, err := Decrypt(&plain, bytes.NewReader(crashed), lookup)
+		assert.ErrorIs(t, err, ErrorTruncated)
+		assert.Equal(t, "lost tail\nfirst\nsecond\n", plain.String())
+	})
+}
+
+func TestReader_Tampered(t *testing.T) {
+	lookup := func(uint32) ([]byte, error) { return key1, nil }
+	data := encryptChunks(StaticKey(1, key1), "first\n", "second\n")
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[streamHeaderSize+chunkHeaderSize] ^= 0xff
+	_, err := io.ReadAll(NewReader(bytes.NewReader(corrupted), lookup))
+	assert.ErrorIs(t, err, ErrorAuthentication)
+
+	_, err = io.ReadAll(NewReader(bytes.NewReader(data), func(uint32) ([]byte, error) { return key2, nil }))
+	assert.ErrorIs(t, err, ErrorAuthentication)
+
+	ring := NewKeyring()
+	_, err = io.ReadAll(NewReader(bytes.NewReader(data), ring.Lookup))
+	assert.ErrorIs(t, err, ErrorUnknownKey)
+	assert.ErrorIs(t, ring.Add(1, []byte("short")), ErrorInvalidKey)
+}
+
+// partialWriter 只接受前 limit 个字节，之后的写入都返回 err
+type partialWriter struct {
+	out   bytes.Buffer
+	limit int
+	err   error
+	calls int
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	w.calls++
+	n := w.limit - w.out.Len()
+	if n >= len(p) {
+		return w.out.Write(p)
+	}
+	if n < 0 {
+		n = 0
+	}
+	w.out.Write(p[:n])
+	return n, w.err
+}
+
+func TestWriter_PartialWrite(t *testing.T) {
+	// 第一个块只写出了部分流头，之后不能再写出新的流头
+	sink := &partialWriter{limit: 10, err: assert.AnError}
+	w := NewWriter(sink, StaticKey(1, key1))
+
+	_, err := w.Write([]byte("alpha\n"))
+	assert.ErrorIs(t, err, assert.AnError)
+	_, err = w.Write([]byte("beta\n"))
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorIs(t, w.Close(), assert.AnError)
+	assert.Equal(t, 1, sink.calls)
+	assert.Equal(t, 10, sink.out.Len())
+
+	// 没有返回错误的短写入同样使流失效
+	sink = &partialWriter{limit: 10}
+	w = NewWriter(sink, StaticKey(1, key1))
+	_, err = w.Write([]byte("alpha\n"))
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+	_, err = w.Write([]byte("beta\n"))
+	assert.ErrorIs(t, err, io.ErrShortWrite)
+	assert.Equal(t, 1, sink.calls)
+}
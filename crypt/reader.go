@@ -0,0 +1,238 @@
+package crypt
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Reader 解密读取器，按顺序读取输入中的流并返回明文
+type Reader struct {
+	r        *bufio.Reader
+	keys     KeyLookup
+	maxSize  int
+	streamID [streamIDSize]byte
+	inStream bool
+	seq      uint64
+	header   [chunkHeaderSize]byte
+	chunk    []byte
+	plain    []byte
+	aad      []byte
+	aeads    map[uint32]cipher.AEAD
+	err      error
+}
+
+// NewReader 创建读取 r 的解密读取器，keys 按编号查找密钥
+func NewReader(r io.Reader, keys KeyLookup) *Reader {
+	return &Reader{
+		r:       bufio.NewReader(r),
+		keys:    keys,
+		maxSize: DefaultMaxChunkSize,
+		aeads:   make(map[uint32]cipher.AEAD),
+	}
+}
+
+// WithMaxChunkSize 设置允许的最大块长度，超过时 Read 返回 ErrorChunkTooLarge
+func (d *Reader) WithMaxChunkSize(size int) *Reader {
+	d.maxSize = size
+	return d
+}
+
+// Read 实现 io.Reader，返回已通过认证的明文。
+//
+// 所有流都完整时在输入结束后返回 io.EOF。一个流缺少结束块时返回 ErrorTruncated：
+// 如果之后还有新的流（例如进程崩溃后重新打开文件追加写入），继续调用 Read 会读取后续的流；
+// 如果输入在块的中间或流的末尾结束，之后的调用会返回同一个错误。其他错误同样会被保留。
+func (d *Reader) Read(p []byte) (int, error) {
+	if err := d.fill(); err != nil {
+		return 0, err
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// WriteTo 实现 io.WriterTo，将已通过认证的明文依次写入 w，错误的含义与 Read 相同。
+// 所有流都完整时返回 nil。
+func (d *Reader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		if len(d.plain) > 0 {
+			n, err := w.Write(d.plain)
+			total += int64(n)
+			d.plain = d.plain[n:]
+			if err != nil {
+				return total, err
+			}
+		}
+		if err := d.fill(); err != nil {
+			if err == io.EOF {
+				return total, nil
+			}
+			return total, err
+		}
+	}
+}
+
+// fill 在没有剩余明文时读取下一个数据块
+func (d *Reader) fill() error {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return d.err
+		}
+		if err := d.next(); err != nil {
+			if errors.Is(err, errTruncatedStream) {
+				return ErrorTruncated
+			}
+			d.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// errTruncatedStream 流缺少结束块，但之后还有新的流
+var errTruncatedStream = errors.New("crypt: stream is truncated before next stream")
+
+// next 读取并解密下一个块，解密后的明文保存在 plain 中
+func (d *Reader) next() error {
+	if !d.inStream {
+		if err := d.readStreamHeader(); err != nil {
+			return err
+		}
+	}
+
+	// 流中出现新的流头，说明上一个流没有正常结束
+	if b, err := d.r.Peek(1); err == nil && b[0] == streamMagic[0] {
+		d.inStream = false
+		return errTruncatedStream
+	}
+
+	if _, err := io.ReadFull(d.r, d.header[:]); err != nil {
+		return truncated(err)
+	}
+	kind := d.header[0]
+	keyID := binary.BigEndian.Uint32(d.header[1:5])
+	seq := binary.BigEndian.Uint64(d.header[5:13])
+	size := binary.BigEndian.Uint32(d.header[13:17])
+
+	if kind != chunkData && kind != chunkFinal {
+		return ErrorInvalidFormat
+	}
+	if size < tagSize {
+		return ErrorInvalidFormat
+	}
+	if int64(size) > int64(d.maxSize) {
+		return ErrorChunkTooLarge
+	}
+	// 序号不连续说明块被删除或调换了顺序
+	if seq != d.seq {
+		return ErrorAuthentication
+	}
+
+	if cap(d.chunk) < int(size) {
+		d.chunk = make([]byte, size)
+	}
+	d.chunk = d.chunk[:size]
+	if _, err := io.ReadFull(d.r, d.chunk); err != nil {
+		return truncated(err)
+	}
+
+	aead, err := d.cipher(keyID)
+	if err != nil {
+		return err
+	}
+	d.aad = appendAAD(d.aad[:0], d.streamID[:], d.header[:chunkAADSize])
+	plain, err := aead.Open(d.chunk[:0], d.header[chunkAADSize:], d.chunk, d.aad)
+	if err != nil {
+		return ErrorAuthentication
+	}
+
+	d.seq++
+	if kind == chunkFinal {
+		if len(plain) != 0 {
+			return ErrorInvalidFormat
+		}
+		d.inStream = false
+		return nil
+	}
+	d.plain = plain
+	return nil
+}
+
+// readStreamHeader 读取流头，输入在流的边界处结束时返回 io.EOF
+func (d *Reader) readStreamHeader() error {
+	var header [streamHeaderSize]byte
+	n, err := io.ReadFull(d.r, header[:])
+	if n == 0 && err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return truncated(err)
+	}
+	if string(header[:len(streamMagic)]) != streamMagic || header[len(streamMagic)] != streamVersion {
+		return ErrorInvalidFormat
+	}
+
+	copy(d.streamID[:], header[len(streamMagic)+1:])
+	d.inStream, d.seq = true, 0
+	return nil
+}
+
+// cipher 返回密钥编号对应的 AES-GCM 实例
+func (d *Reader) cipher(id uint32) (cipher.AEAD, error) {
+	if aead, ok := d.aeads[id]; ok {
+		return aead, nil
+	}
+	secret, err := d.keys(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	d.aeads[id] = aead
+	return aead, nil
+}
+
+// truncated 将块中间遇到的 EOF 转换为 ErrorTruncated
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrorTruncated
+	}
+	return err
+}
+
+// DecryptFile 解密 path 指向的文件并写入 w，返回写入的明文字节数。
+// 已通过认证的明文会在返回 ErrorTruncated 之前全部写入 w。
+func DecryptFile(w io.Writer, path string, keys KeyLookup) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return Decrypt(w, f, keys)
+}
+
+// Decrypt 解密 r 中的所有流并写入 w，返回写入的明文字节数。
+// 遇到中间被截断的流时继续读取后续的流，最后返回 ErrorTruncated。
+func Decrypt(w io.Writer, r io.Reader, keys KeyLookup) (int64, error) {
+	reader := NewReader(r, keys)
+	var total int64
+	var truncatedErr error
+	for {
+		n, err := io.Copy(w, reader)
+		total += n
+		if err == nil {
+			return total, truncatedErr
+		}
+		if !errors.Is(err, ErrorTruncated) || reader.err != nil {
+			return total, err
+		}
+		truncatedErr = err
+	}
+}
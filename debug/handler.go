@@ -0,0 +1,231 @@
+// Package debug 提供一个类似 net/http/pprof 的 http.Handler，用于查看已注册写入器的实时状态。
+//
+// 页面展示每个写入器的运行状态、队列长度、缓冲字节数、距上次刷新的时间、配置值以及最近的失败记录，
+// 并支持手动触发刷新。请求带上 format=json 参数时以 JSON 格式返回同样的内容。
+//
+// 该包不会自动注册到 http.DefaultServeMux，需要手动挂载：
+//
+//	debug.Register("app", w)
+//	http.Handle("/debug/law/", debug.DefaultHandler)
+//
+// DefaultHandler 同时展示通过 law.Config.WithName 或 law.Register 加入 law 注册表的写入器。
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/shengyanli1982/law/internal/registry"
+)
+
+// 错误定义
+var (
+	ErrorWriterNameIsEmpty   = registry.ErrorNameIsEmpty
+	ErrorWriterIsNil         = law.ErrorWriterIsNil
+	ErrorWriterAlreadyExists = law.ErrorNameInUse
+	ErrorWriterNotRegistered = registry.ErrorNotRegistered
+)
+
+// Target 定义了可被调试页面展示的写入器，*law.WriteAsyncer 实现了该接口
+type Target interface {
+	// Stats 返回运行状态快照
+	Stats() law.Stats
+
+	// Config 返回当前配置
+	Config() *law.Config
+
+	// RecentErrors 返回最近的失败记录
+	RecentErrors() []law.WriteError
+
+	// FlushContext 立即刷新，ctx 结束时不再等待并返回 ctx.Err()
+	FlushContext(ctx context.Context) error
+}
+
+// Handler 调试页面处理器，按名称管理多个写入器
+type Handler struct {
+	targets     *registry.Named[Target]
+	useRegistry bool
+}
+
+// DefaultHandler 默认的调试页面处理器，供包级 Register 和 Unregister 使用，同时展示 law 注册表中有名称的写入器
+var DefaultHandler = NewHandler().WithRegistry(true)
+
+// NewHandler 创建新的调试页面处理器
+func NewHandler() *Handler {
+	return &Handler{targets: registry.New[Target]()}
+}
+
+// WithRegistry 设置是否同时展示 law 注册表中有名称的写入器（见 law.Config.WithName 和 law.Register），
+// 名称与通过 Register 注册的写入器相同时以后者为准。应在开始处理请求前设置。
+func (h *Handler) WithRegistry(enabled bool) *Handler {
+	h.useRegistry = enabled
+	return h
+}
+
+// Register 以名称注册一个写入器
+func (h *Handler) Register(name string, target Target) error {
+	return h.targets.Register(name, target)
+}
+
+// Unregister 注销指定名称的写入器
+func (h *Handler) Unregister(name string) error {
+	return h.targets.Unregister(name)
+}
+
+// Register 在 DefaultHandler 上注册一个写入器
+func Register(name string, target Target) error {
+	return DefaultHandler.Register(name, target)
+}
+
+// Unregister 从 DefaultHandler 上注销一个写入器
+func Unregister(name string) error {
+	return DefaultHandler.Unregister(name)
+}
+
+// lookup 按名称查找写入器，开启 WithRegistry 时还会查找 law 注册表
+func (h *Handler) lookup(name string) (Target, bool) {
+	if target, ok := h.targets.Lookup(name); ok {
+		return target, true
+	}
+	if h.useRegistry {
+		if wa := law.Lookup(name); wa != nil {
+			return wa, true
+		}
+	}
+	return nil, false
+}
+
+// ServeHTTP 实现 http.Handler。
+// GET 请求返回状态页面；POST 请求带上 flush=<name> 参数时刷新对应的写入器，
+// 刷新在请求的 context 结束前没有完成时，超时返回 504，请求被取消返回 503。
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.serveStatus(w, r)
+	case http.MethodPost:
+		h.serveFlush(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveStatus 渲染所有写入器的状态
+func (h *Handler) serveStatus(w http.ResponseWriter, r *http.Request) {
+	page := h.snapshot()
+
+	if r.FormValue("format") == "json" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(page.Writers)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = statusTemplate.Execute(w, page)
+}
+
+// serveFlush 刷新指定的写入器，完成后重定向回状态页面
+func (h *Handler) serveFlush(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("flush")
+	target, ok := h.lookup(name)
+	if !ok {
+		http.Error(w, ErrorWriterNotRegistered.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := target.FlushContext(r.Context()); err != nil {
+		http.Error(w, "flush failed: "+err.Error(), flushStatus(err))
+		return
+	}
+
+	if r.FormValue("format") == "json" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
+
+// flushStatus 返回刷新失败对应的状态码
+func flushStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writerStatus 单个写入器的展示数据
+type writerStatus struct {
+	Name              string        `json:"name"`
+	Running           bool          `json:"running"`
+	QueueLength       int           `json:"queue_length"`
+	BufferedBytes     int           `json:"buffered_bytes"`
+	LastFlushAt       time.Time     `json:"last_flush_at"`
+	SinceLastFlush    time.Duration `json:"since_last_flush_ns"`
+	BufferSize        int           `json:"buffer_size"`
+	HeartbeatInterval time.Duration `json:"heartbeat_interval_ns"`
+	IdleTimeout       time.Duration `json:"idle_timeout_ns"`
+	Stats             law.Stats     `json:"-"`
+	RecentErrors      []errorStatus `json:"recent_errors"`
+}
+
+// errorStatus 单次失败的展示数据
+type errorStatus struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error"`
+	Bytes int       `json:"bytes"`
+}
+
+// statusPage 状态页面的展示数据
+type statusPage struct {
+	Now     time.Time
+	Writers []writerStatus
+}
+
+// snapshot 按名称顺序采集所有写入器的展示数据
+func (h *Handler) snapshot() statusPage {
+	entries := h.targets.Snapshot()
+	if h.useRegistry {
+		entries = registry.Include(entries, law.RegisteredNames(), func(name string) (Target, bool) {
+			wa := law.Lookup(name)
+			return wa, wa != nil
+		})
+	}
+
+	page := statusPage{Now: time.Now(), Writers: make([]writerStatus, 0, len(entries))}
+	for _, entry := range entries {
+		name, target := entry.Name, entry.Value
+		stats := target.Stats()
+		conf := target.Config()
+
+		ws := writerStatus{
+			Name:              name,
+			Running:           stats.Running,
+			QueueLength:       stats.QueueLength,
+			BufferedBytes:     stats.BufferedBytes,
+			LastFlushAt:       stats.LastFlushAt,
+			BufferSize:        conf.BufferSize(),
+			HeartbeatInterval: conf.HeartbeatInterval(),
+			IdleTimeout:       conf.IdleTimeout(),
+			Stats:             stats,
+		}
+		if !stats.LastFlushAt.IsZero() {
+			ws.SinceLastFlush = page.Now.Sub(stats.LastFlushAt)
+		}
+		for _, e := range target.RecentErrors() {
+			ws.RecentErrors = append(ws.RecentErrors, errorStatus{At: e.At, Error: e.Err.Error(), Bytes: e.Bytes})
+		}
+
+		page.Writers = append(page.Writers, ws)
+	}
+	return page
+}
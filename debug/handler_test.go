@@ -0,0 +1,154 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Register(t *testing.T) {
+	h := NewHandler()
+	w := law.NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+	defer w.Stop()
+
+	assert.ErrorIs(t, h.Register("", w), ErrorWriterNameIsEmpty)
+	assert.ErrorIs(t, h.Register("app", nil), ErrorWriterIsNil)
+	assert.Nil(t, h.Register("app", w))
+	assert.ErrorIs(t, h.Register("app", w), ErrorWriterAlreadyExists)
+	assert.Nil(t, h.Unregister("app"))
+	assert.ErrorIs(t, h.Unregister("app"), ErrorWriterNotRegistered)
+}
+
+func TestHandler_Status(t *testing.T) {
+	h := NewHandler()
+	w := law.NewWriteAsyncer(bytes.NewBuffer(nil), law.NewConfig().WithBufferSize(4096).WithIdleTimeout(time.Minute))
+	defer w.Stop()
+	assert.Nil(t, h.Register("access", w))
+
+	t.Run("html", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/law/", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "<h2>access</h2>")
+		assert.Contains(t, body, "running")
+		assert.Contains(t, body, "<td>4096</td>")
+		assert.Contains(t, body, "<td>1m0s</td>")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/law/?format=json", nil))
+
+		var writers []map[string]any
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &writers))
+		assert.Len(t, writers, 1)
+		assert.Equal(t, "access", writers[0]["name"])
+		assert.Equal(t, true, writers[0]["running"])
+		assert.Equal(t, float64(4096), writers[0]["buffer_size"])
+	})
+}
+
+func TestHandler_Flush(t *testing.T) {
+	h := NewHandler()
+	buff := bytes.NewBuffer(nil)
+	w := law.NewWriteAsyncer(buff, nil)
+	defer w.Stop()
+	assert.Nil(t, h.Register("app", w))
+
+	_, err := w.Write([]byte("hello"))
+	assert.Nil(t, err)
+
+	form := url.Values{"flush": {"app"}}
+	req := httptest.NewRequest(http.MethodPost, "/debug/law/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/debug/law/", rec.Header().Get("Location"))
+	assert.Equal(t, "hello", buff.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/law/?flush=missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/debug/law/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// blockingWriter 在 release 关闭前阻塞写入
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestHandler_FlushContext(t *testing.T) {
+	h := NewHandler()
+	sink := &blockingWriter{release: make(chan struct{})}
+	w := law.NewWriteAsyncer(sink, nil)
+	defer w.Stop()
+	defer close(sink.release)
+	assert.Nil(t, h.Register("slow", w))
+
+	_, err := w.Write([]byte("hello"))
+	assert.Nil(t, err)
+
+	// 写入器阻塞时，请求超时返回 504
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/law/?flush=slow", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	// 请求被取消返回 503
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/law/?flush=slow", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestHandler_WithRegistry(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := law.NewWriteAsyncer(buff, law.NewConfig().WithName("debug-named").WithIdleTimeout(time.Minute))
+	defer w.Stop()
+
+	h := NewHandler().WithRegistry(true)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/law/?format=json", nil))
+	var writers []map[string]any
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &writers))
+	assert.Len(t, writers, 1)
+	assert.Equal(t, "debug-named", writers[0]["name"])
+
+	// 注册表中的写入器也可以手动刷新
+	_, _ = w.Write([]byte("named\n"))
+	form := url.Values{"flush": {"debug-named"}, "format": {"json"}}
+	req := httptest.NewRequest(http.MethodPost, "/debug/law/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// 默认不展示注册表中的写入器
+	rec = httptest.NewRecorder()
+	NewHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/law/?format=json", nil))
+	assert.NotContains(t, rec.Body.String(), "debug-named")
+}
@@ -0,0 +1,67 @@
+package debug
+
+import (
+	"html/template"
+	"time"
+)
+
+// templateFuncs 状态页面使用的模板函数
+var templateFuncs = template.FuncMap{
+	"since": func(d time.Duration) string {
+		if d == 0 {
+			return "never"
+		}
+		return d.Round(time.Millisecond).String() + " ago"
+	},
+	"timestamp": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+}
+
+// statusTemplate 状态页面模板
+var statusTemplate = template.Must(template.New("status").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>law writers</title>
+<style>
+body { font-family: monospace; margin: 1em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+.stopped { color: #c00; }
+</style>
+</head>
+<body>
+<h1>law writers</h1>
+<p>Generated at {{timestamp .Now}}</p>
+{{- if not .Writers}}
+<p>No writers registered.</p>
+{{- end}}
+{{- range .Writers}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>status</th><td>{{if .Running}}running{{else}}<span class="stopped">stopped</span>{{end}}</td></tr>
+<tr><th>queue length</th><td>{{.QueueLength}}</td></tr>
+<tr><th>buffered bytes</th><td>{{.BufferedBytes}}</td></tr>
+<tr><th>last flush</th><td>{{since .SinceLastFlush}}</td></tr>
+<tr><th>enqueued records</th><td>{{.Stats.EnqueuedRecords}}</td></tr>
+<tr><th>written records</th><td>{{.Stats.WrittenRecords}}</td></tr>
+<tr><th>failed records</th><td>{{.Stats.FailedRecords}}</td></tr>
+<tr><th>flushes / errors</th><td>{{.Stats.Flushes}} / {{.Stats.FlushErrors}}</td></tr>
+<tr><th>buffer size</th><td>{{.BufferSize}}</td></tr>
+<tr><th>heartbeat interval</th><td>{{.HeartbeatInterval}}</td></tr>
+<tr><th>idle timeout</th><td>{{.IdleTimeout}}</td></tr>
+</table>
+{{- if .RecentErrors}}
+<table>
+<tr><th>time</th><th>bytes</th><th>error</th></tr>
+{{- range .RecentErrors}}
+<tr><td>{{timestamp .At}}</td><td>{{.Bytes}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<form method="post"><input type="hidden" name="flush" value="{{.Name}}"><button type="submit"{{if not .Running}} disabled{{end}}>Flush</button></form>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,62 @@
+package law
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_Dedup(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithDedup(time.Hour))
+
+	for _, line := range []string{"a\n", "b\n", "b\n", "b\n", "c\n", "c\n"} {
+		_, err := w.Write([]byte(line))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Flush())
+
+	// Flush 结束当前一轮，之后相同的记录重新写出
+	_, _ = w.Write([]byte("c\n"))
+	w.Stop()
+
+	assert.Equal(t, "a\nb\nlast message repeated 2 times\nc\nlast message repeated 1 times\nc\n", buff.String())
+	assert.Equal(t, uint64(3), w.Stats().RepeatedRecords)
+}
+
+func TestWriteAsyncer_DedupWindow(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithDedup(20*time.Millisecond))
+
+	_, _ = w.Write([]byte("x\n"))
+	_, _ = w.Write([]byte("x\n"))
+	assert.Eventually(t, func() bool {
+		return w.Stats().RepeatedRecords == 1
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = w.Write([]byte("x\n"))
+	w.Stop()
+
+	assert.Equal(t, "x\nlast message repeated 1 times\nx\n", buff.String())
+}
+
+func TestWriteAsyncer_DedupIdleFlush(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().WithDedup(time.Hour).WithHeartbeatInterval(10 * time.Millisecond).WithIdleTimeout(10 * time.Millisecond)
+	w := NewWriteAsyncer(buff, conf)
+
+	for i := 0; i < 5; i++ {
+		_, _ = w.Write([]byte("same\n"))
+	}
+
+	// 闲置刷新写出重复摘要，摘要也计入写出的记录；轮询器的时钟每秒更新一次
+	assert.Eventually(t, func() bool {
+		return w.Stats().WrittenRecords == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	w.Stop()
+
+	assert.Equal(t, "same\nlast message repeated 4 times\n", buff.String())
+}
@@ -0,0 +1,33 @@
+package law
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/shengyanli1982/law/crypt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_Encryption(t *testing.T) {
+	ring := crypt.NewKeyring()
+	assert.Nil(t, ring.Rotate(1, bytes.Repeat([]byte{7}, 32)))
+
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithEncryption(ring.Current).WithBufferSize(16))
+
+	_, _ = w.Write([]byte("secret: alpha\n"))
+	assert.Nil(t, w.Flush())
+	assert.Nil(t, ring.Rotate(2, bytes.Repeat([]byte{8}, 16)))
+	_, _ = w.Write([]byte("secret: beta\n"))
+	w.Stop()
+
+	assert.False(t, bytes.Contains(buff.Bytes(), []byte("secret")))
+	plain, err := io.ReadAll(crypt.NewReader(bytes.NewReader(buff.Bytes()), ring.Lookup))
+	assert.Nil(t, err)
+	assert.Equal(t, "secret: alpha\nsecret: beta\n", string(plain))
+
+	// 没有结束块的输出被识别为截断
+	_, err = io.ReadAll(crypt.NewReader(bytes.NewReader(buff.Bytes()[:buff.Len()-1]), ring.Lookup))
+	assert.ErrorIs(t, err, crypt.ErrorTruncated)
+}
@@ -0,0 +1,121 @@
+// Package frame 定义了 law 的二进制分帧格式，以及读取、检查和修复分帧文件的工具。
+//
+// 每条记录被编码为一帧：
+//
+//	flags (1 字节) | [sequence (uvarint)] | length (uvarint) | payload | [crc32c (4 字节，小端)]
+//
+// flags 的高 4 位固定为 0xA，用于识别帧的起始位置；低位记录该帧是否携带序号和校验和，
+// 因此读取时不需要知道写入时的配置。校验和使用 CRC-32C（Castagnoli），覆盖 flags 到 payload 的全部内容。
+//
+// 进程崩溃时文件末尾可能留下不完整的帧。Scan 返回最后一个完整帧的结束位置和序号，
+// Repair 会截掉不完整的尾部，之后可以追加写入，并通过 law.Config.WithFrameSequenceStart 继续编号；文件中间的帧损坏时不修改文件，只返回错误。
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// Flags 分帧选项
+type Flags uint8
+
+const (
+	// LengthPrefix 每帧以 uvarint 长度开头，设置了其他选项时自动包含
+	LengthPrefix Flags = 1 << iota
+
+	// Checksum 每帧以 CRC-32C 校验和结尾
+	Checksum
+
+	// Sequence 每帧携带从写入器启动时开始递增的序号
+	Sequence
+)
+
+// magic flags 字节的高 4 位
+const (
+	magic     = 0xA0
+	magicMask = 0xF0
+	flagsMask = byte(LengthPrefix | Checksum | Sequence)
+)
+
+// checksumSize 校验和的长度
+const checksumSize = 4
+
+// MaxHeaderSize 帧头的最大长度
+const MaxHeaderSize = 1 + 2*binary.MaxVarintLen64
+
+// DefaultMaxFrameSize 读取时允许的默认最大负载长度
+const DefaultMaxFrameSize = 64 * 1024 * 1024
+
+// 错误定义
+var (
+	ErrorTornFrame        = errors.New("frame: torn frame at end of input")
+	ErrorChecksumMismatch = errors.New("frame: checksum mismatch")
+	ErrorInvalidFrame     = errors.New("frame: invalid frame header")
+	ErrorFrameTooLarge    = errors.New("frame: frame exceeds maximum size")
+)
+
+// castagnoli CRC-32C 表
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Normalize 返回补齐 LengthPrefix 后的选项，flags 为 0 时返回 0 表示不分帧
+func (f Flags) Normalize() Flags {
+	if f == 0 {
+		return 0
+	}
+	return (f | LengthPrefix) & Flags(flagsMask)
+}
+
+// Encoder 帧编码器，记录下一帧的序号，不能被多个协程并发使用
+type Encoder struct {
+	flags Flags
+	seq   uint64
+}
+
+// NewEncoder 创建帧编码器，firstSeq 为第一帧的序号
+func NewEncoder(flags Flags, firstSeq uint64) *Encoder {
+	flags = flags.Normalize()
+	if flags == 0 {
+		flags = LengthPrefix
+	}
+	return &Encoder{flags: flags, seq: firstSeq}
+}
+
+// Flags 返回编码器的选项
+func (e *Encoder) Flags() Flags {
+	return e.flags
+}
+
+// NextSequence 返回下一帧的序号
+func (e *Encoder) NextSequence() uint64 {
+	return e.seq
+}
+
+// AppendHeader 将长度为 size 的负载的帧头追加到 dst，携带序号时序号递增
+func (e *Encoder) AppendHeader(dst []byte, size int) []byte {
+	dst = append(dst, magic|byte(e.flags))
+	if e.flags&Sequence != 0 {
+		dst = binary.AppendUvarint(dst, e.seq)
+		e.seq++
+	}
+	return binary.AppendUvarint(dst, uint64(size))
+}
+
+// AppendTrailer 将帧尾追加到 dst，header 和 payload 为同一帧的帧头和负载。
+// 未开启 Checksum 时不追加任何内容。
+func (e *Encoder) AppendTrailer(dst, header, payload []byte) []byte {
+	if e.flags&Checksum == 0 {
+		return dst
+	}
+	crc := crc32.Update(crc32.Checksum(header, castagnoli), castagnoli, payload)
+	return binary.LittleEndian.AppendUint32(dst, crc)
+}
+
+// Append 将 payload 编码为一帧追加到 dst
+func (e *Encoder) Append(dst, payload []byte) []byte {
+	start := len(dst)
+	dst = e.AppendHeader(dst, len(payload))
+	headerEnd := len(dst)
+	dst = append(dst, payload...)
+	return e.AppendTrailer(dst, dst[start:headerEnd], payload)
+}
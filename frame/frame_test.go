@@ -0,0 +1,138 @@
+package frame
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeAll(enc *Encoder, payloads ...string) []byte {
+	var out []byte
+	for _, p := range payloads {
+		out = enc.Append(out, []byte(p))
+	}
+	return out
+}
+
+func TestReader_RoundTrip(t *testing.T) {
+	for _, flags := range []Flags{LengthPrefix, Checksum, Sequence, Checksum | Sequence} {
+		data := encodeAll(NewEncoder(flags, 10), "alpha", "", "gamma")
+		r := NewReader(bytes.NewReader(data))
+
+		var payloads []string
+		var seqs []uint64
+		for {
+			f, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, flags.Normalize(), f.Flags)
+			payloads = append(payloads, string(f.Payload))
+			seqs = append(seqs, f.Sequence)
+		}
+
+		assert.Equal(t, []string{"alpha", "", "gamma"}, payloads)
+		if flags&Sequence != 0 {
+			assert.Equal(t, []uint64{10, 11, 12}, seqs)
+		}
+		offset, _, _ := r.Position()
+		assert.Equal(t, int64(len(data)), offset)
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	enc := NewEncoder(Checksum|Sequence, 1)
+	data := encodeAll(enc, "first", "second")
+	firstSize := len(NewEncoder(Checksum|Sequence, 1).Append(nil, []byte("first")))
+
+	t.Run("torn tail", func(t *testing.T) {
+		for cut := firstSize + 1; cut < len(data); cut++ {
+			r := NewReader(bytes.NewReader(data[:cut]))
+			_, err := r.Next()
+			assert.Nil(t, err)
+			_, err = r.Next()
+			assert.ErrorIs(t, err, ErrorTornFrame)
+
+			offset, seq, ok := r.Position()
+			assert.Equal(t, int64(firstSize), offset)
+			assert.Equal(t, uint64(1), seq)
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		corrupted := append([]byte(nil), data...)
+		corrupted[len(corrupted)-6] ^= 0xff
+		r := NewReader(bytes.NewReader(corrupted))
+		_, _ = r.Next()
+		_, err := r.Next()
+		assert.ErrorIs(t, err, ErrorChecksumMismatch)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte{0x00, 0x01})).Next()
+		assert.ErrorIs(t, err, ErrorInvalidFrame)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader(data)).WithMaxFrameSize(4).Next()
+		assert.ErrorIs(t, err, ErrorFrameTooLarge)
+	})
+}
+
+func TestRepair(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	data := encodeAll(NewEncoder(Checksum|Sequence, 0), "a", "b", "c")
+	assert.Nil(t, os.WriteFile(path, data[:len(data)-2], 0o644))
+
+	result, err := Repair(path)
+	assert.Nil(t, err)
+	assert.True(t, result.Torn())
+	assert.Equal(t, 2, result.Frames)
+	assert.Equal(t, uint64(2), result.NextSequence())
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, result.ValidSize, info.Size())
+
+	// 修复后追加的帧可以被连续读取
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	assert.Nil(t, err)
+	_, err = f.Write(NewEncoder(Checksum|Sequence, result.NextSequence()).Append(nil, []byte("c")))
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	result, err = Repair(path)
+	assert.Nil(t, err)
+	assert.False(t, result.Torn())
+	assert.Equal(t, 3, result.Frames)
+	assert.Equal(t, uint64(2), result.LastSeq)
+}
+
+func TestRepair_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	firstSize := len(encodeAll(NewEncoder(Checksum|Sequence, 0), "first"))
+	data := encodeAll(NewEncoder(Checksum|Sequence, 0), "first", "second", "third", "fourth")
+
+	// 第二帧中的一个字节被修改，之后的帧仍然完整
+	data[firstSize+4] ^= 0xff
+	assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+	result, err := Repair(path)
+	assert.ErrorIs(t, err, ErrorChecksumMismatch)
+	assert.Contains(t, err.Error(), path)
+	assert.True(t, result.Corrupted())
+	assert.False(t, result.Torn())
+	assert.Equal(t, 1, result.Frames)
+	assert.Equal(t, int64(firstSize), result.ValidSize)
+
+	// 文件没有被截断
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, data, content)
+}
@@ -0,0 +1,247 @@
+package frame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Frame 一个已解码的帧
+type Frame struct {
+	Flags    Flags  // 帧的选项
+	Sequence uint64 // 序号，未携带序号时为 0
+	Payload  []byte // 负载，只在下一次调用 Next 之前有效
+	Offset   int64  // 帧在输入中的起始位置
+}
+
+// Reader 按顺序读取输入中的帧
+type Reader struct {
+	r        *bufio.Reader
+	offset   int64
+	maxSize  int
+	header   []byte
+	payload  []byte
+	err      error
+	lastSeq  uint64
+	hasSeq   bool
+	complete int64
+}
+
+// NewReader 创建读取 r 的 Reader
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:       bufio.NewReader(r),
+		maxSize: DefaultMaxFrameSize,
+		header:  make([]byte, 0, MaxHeaderSize),
+	}
+}
+
+// WithMaxFrameSize 设置允许的最大负载长度，超过时 Next 返回 ErrorFrameTooLarge
+func (r *Reader) WithMaxFrameSize(size int) *Reader {
+	r.maxSize = size
+	return r
+}
+
+// Next 返回下一帧。
+// 输入在帧边界处结束时返回 io.EOF；输入在帧的中间结束时返回 ErrorTornFrame；
+// 帧头无效、负载过大或校验和不匹配时返回相应的错误。返回错误后继续调用 Next 会返回同一个错误。
+func (r *Reader) Next() (Frame, error) {
+	if r.err != nil {
+		return Frame{}, r.err
+	}
+
+	frame, err := r.next()
+	if err != nil {
+		r.err = err
+		return Frame{}, err
+	}
+
+	r.complete = r.offset
+	if frame.Flags&Sequence != 0 {
+		r.lastSeq, r.hasSeq = frame.Sequence, true
+	}
+	return frame, nil
+}
+
+// next 解码一帧
+func (r *Reader) next() (Frame, error) {
+	frame := Frame{Offset: r.offset}
+
+	b, err := r.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return frame, io.EOF
+		}
+		return frame, err
+	}
+	r.offset++
+	if b&magicMask != magic || b&^(magicMask|flagsMask) != 0 || b&byte(LengthPrefix) == 0 {
+		return frame, ErrorInvalidFrame
+	}
+	frame.Flags = Flags(b &^ magicMask)
+	r.header = append(r.header[:0], b)
+
+	if frame.Flags&Sequence != 0 {
+		if frame.Sequence, err = r.readUvarint(); err != nil {
+			return frame, err
+		}
+	}
+
+	size, err := r.readUvarint()
+	if err != nil {
+		return frame, err
+	}
+	if size > uint64(r.maxSize) {
+		return frame, ErrorFrameTooLarge
+	}
+
+	if cap(r.payload) < int(size) {
+		r.payload = make([]byte, size)
+	}
+	frame.Payload = r.payload[:size]
+	if err = r.readFull(frame.Payload); err != nil {
+		return frame, err
+	}
+
+	if frame.Flags&Checksum != 0 {
+		var trailer [checksumSize]byte
+		if err = r.readFull(trailer[:]); err != nil {
+			return frame, err
+		}
+		crc := crc32.Update(crc32.Checksum(r.header, castagnoli), castagnoli, frame.Payload)
+		if crc != binary.LittleEndian.Uint32(trailer[:]) {
+			return frame, ErrorChecksumMismatch
+		}
+	}
+
+	return frame, nil
+}
+
+// readUvarint 读取帧头中的一个 uvarint，并把读到的字节追加到 header
+func (r *Reader) readUvarint() (uint64, error) {
+	start := len(r.header)
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return 0, tornError(err)
+		}
+		r.offset++
+		r.header = append(r.header, b)
+		if b < 0x80 {
+			v, n := binary.Uvarint(r.header[start:])
+			if n <= 0 {
+				return 0, ErrorInvalidFrame
+			}
+			return v, nil
+		}
+	}
+	return 0, ErrorInvalidFrame
+}
+
+// readFull 读满 buf
+func (r *Reader) readFull(buf []byte) error {
+	n, err := io.ReadFull(r.r, buf)
+	r.offset += int64(n)
+	return tornError(err)
+}
+
+// tornError 将帧中间遇到的 EOF 转换为 ErrorTornFrame
+func tornError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrorTornFrame
+	}
+	return err
+}
+
+// Position 返回最后一个完整帧的结束位置和序号，ok 表示是否读到过携带序号的帧
+func (r *Reader) Position() (offset int64, lastSeq uint64, ok bool) {
+	return r.complete, r.lastSeq, r.hasSeq
+}
+
+// ScanResult 扫描结果
+type ScanResult struct {
+	Frames      int    // 完整帧的数量
+	ValidSize   int64  // 最后一个完整帧的结束位置
+	LastSeq     uint64 // 最后一个携带序号的帧的序号
+	HasSequence bool   // 是否读到过携带序号的帧
+	Err         error  // 停止扫描的原因，输入完整时为 nil
+}
+
+// Torn 判断输入是否在帧的中间结束，即最后一个完整帧之后直到输入末尾都是不完整的帧，通常是崩溃时写了一半的帧
+func (s ScanResult) Torn() bool {
+	return errors.Is(s.Err, ErrorTornFrame)
+}
+
+// Corrupted 判断扫描是否因帧头无效、负载过大或校验和不匹配而停止，此时损坏位置之后可能还有完整的帧
+func (s ScanResult) Corrupted() bool {
+	return s.Err != nil && !s.Torn()
+}
+
+// NextSequence 返回继续写入时应使用的第一个序号
+func (s ScanResult) NextSequence() uint64 {
+	if !s.HasSequence {
+		return 0
+	}
+	return s.LastSeq + 1
+}
+
+// Scan 读取 r 中的所有帧，返回完整帧的统计。
+// 遇到不完整或损坏的帧时停止，原因记录在 ScanResult.Err 中；读取 r 本身失败时返回错误。
+func Scan(r io.Reader) (ScanResult, error) {
+	reader := NewReader(r)
+	var result ScanResult
+	for {
+		_, err := reader.Next()
+		if err != nil {
+			result.ValidSize, result.LastSeq, result.HasSequence = reader.Position()
+			switch {
+			case err == io.EOF:
+				return result, nil
+			case isFrameError(err):
+				result.Err = err
+				return result, nil
+			default:
+				return result, err
+			}
+		}
+		result.Frames++
+	}
+}
+
+// isFrameError 判断错误是否是数据本身的问题
+func isFrameError(err error) bool {
+	return errors.Is(err, ErrorTornFrame) || errors.Is(err, ErrorChecksumMismatch) ||
+		errors.Is(err, ErrorInvalidFrame) || errors.Is(err, ErrorFrameTooLarge)
+}
+
+// Repair 扫描 path 指向的文件，末尾有不完整的帧时截掉最后一个完整帧之后的内容，返回扫描结果。
+// 文件中间有损坏的帧（ScanResult.Corrupted）时不修改文件，返回包含文件路径和位置的 ScanResult.Err，
+// 以免截断损坏位置之后完整的帧，此时文件需要人工检查。文件应在没有写入器打开的情况下修复。
+func Repair(path string) (ScanResult, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer f.Close()
+
+	result, err := Scan(f)
+	if err != nil {
+		return result, err
+	}
+	if result.Corrupted() {
+		return result, fmt.Errorf("%s: offset %d: %w", path, result.ValidSize, result.Err)
+	}
+	if result.Torn() {
+		if err = f.Truncate(result.ValidSize); err != nil {
+			return result, err
+		}
+		if err = f.Sync(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package law
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/shengyanli1982/law/frame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_Framing(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().WithFraming(frame.Checksum | frame.Sequence).WithFrameSequenceStart(100).WithBufferSize(16)
+	w := NewWriteAsyncer(buff, conf)
+
+	_, _ = w.Write([]byte{0x00, 0x01, 0x02})
+	_, _ = w.Write(bytes.Repeat([]byte{0xff}, 40))
+	_ = w.WriteRecord(RecordFunc(func(b *bytes.Buffer) error {
+		b.WriteString("record")
+		return nil
+	}))
+	w.Stop()
+
+	r := frame.NewReader(bytes.NewReader(buff.Bytes()))
+	var payloads [][]byte
+	var seqs []uint64
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		payloads = append(payloads, append([]byte(nil), f.Payload...))
+		seqs = append(seqs, f.Sequence)
+	}
+
+	assert.Equal(t, [][]byte{{0x00, 0x01, 0x02}, bytes.Repeat([]byte{0xff}, 40), []byte("record")}, payloads)
+	assert.Equal(t, []uint64{100, 101, 102}, seqs)
+	assert.Equal(t, uint64(buff.Len()), w.Stats().WrittenBytes)
+}
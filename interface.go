@@ -11,6 +11,27 @@ type Writer interface {
 	Stop()
 }
 
+// Record 定义了延迟编码的记录接口
+type Record interface {
+	// EncodeTo 将记录编码后追加到缓冲区，在轮询器协程上调用
+	EncodeTo(buff *bytes.Buffer) error
+}
+
+// Releaser 定义了可回收的记录接口，记录编码完成（无论成功与否）后调用 Release，
+// 可用于将记录对象归还到调用方自己的对象池
+type Releaser interface {
+	// Release 归还记录持有的资源
+	Release()
+}
+
+// RecordFunc 是将编码函数适配为 Record 的函数类型
+type RecordFunc func(buff *bytes.Buffer) error
+
+// EncodeTo 调用编码函数
+func (f RecordFunc) EncodeTo(buff *bytes.Buffer) error {
+	return f(buff)
+}
+
 // Callback 定义了回调接口
 type Callback interface {
 	// OnWriteFailed 当写入失败时被调用
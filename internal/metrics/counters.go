@@ -0,0 +1,29 @@
+package metrics
+
+import "sync/atomic"
+
+// Counters 写入器的运行时计数器，所有字段都可以被并发读写
+type Counters struct {
+	EnqueuedRecords   atomic.Uint64 // 被 Write 接收并入队的记录数
+	EnqueuedBytes     atomic.Uint64 // 被 Write 接收并入队的字节数
+	WrittenRecords    atomic.Uint64 // 成功写入缓冲写入器的记录数
+	WrittenBytes      atomic.Uint64 // 成功写入缓冲写入器的字节数
+	FailedRecords     atomic.Uint64 // 写入失败的记录数
+	Flushes           atomic.Uint64 // 刷新底层 io.Writer 的次数
+	FlushErrors       atomic.Uint64 // 刷新失败的次数
+	BufferedBytes     atomic.Int64  // 缓冲写入器中尚未刷新的字节数
+	LastFlushAt       atomic.Int64  // 最近一次成功刷新的时间（Unix 纳秒），为 0 表示尚未刷新
+	RejectedRecords   atomic.Uint64 // 因超过最大长度被拒绝的记录数
+	TruncatedRecords  atomic.Uint64 // 因超过最大长度被截断的记录数
+	SplitRecords      atomic.Uint64 // 因超过最大长度被拆分的记录数
+	FilteredRecords   atomic.Uint64 // 被转换阶段丢弃的记录数
+	TransformErrors   atomic.Uint64 // 转换阶段返回错误的次数
+	SuppressedRecords atomic.Uint64 // 被采样器丢弃的记录数
+	RepeatedRecords   atomic.Uint64 // 被合并为重复摘要的记录数
+	DiscardedRecords  atomic.Uint64 // 暂停期间停止时被丢弃的记录数
+}
+
+// NewCounters 创建一组新的计数器
+func NewCounters() *Counters {
+	return &Counters{}
+}
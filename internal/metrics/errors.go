@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrorEvent 一次写入或刷新失败的记录
+type ErrorEvent struct {
+	At    time.Time // 发生时间
+	Err   error     // 失败原因
+	Bytes int       // 受影响的字节数，刷新失败时为缓冲区中的字节数
+}
+
+// ErrorRing 保存最近若干次失败的环形缓冲区
+type ErrorRing struct {
+	mu     sync.Mutex
+	events []ErrorEvent
+	next   int
+	full   bool
+}
+
+// NewErrorRing 创建容量为 size 的环形缓冲区，size <= 0 时容量为 1
+func NewErrorRing(size int) *ErrorRing {
+	if size <= 0 {
+		size = 1
+	}
+	return &ErrorRing{events: make([]ErrorEvent, size)}
+}
+
+// Add 记录一次失败，超过容量时覆盖最旧的记录
+func (r *ErrorRing) Add(err error, bytes int) {
+	r.mu.Lock()
+	r.events[r.next] = ErrorEvent{At: time.Now(), Err: err, Bytes: bytes}
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+// Events 按时间从新到旧返回所有记录
+func (r *ErrorRing) Events() []ErrorEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.next
+	if r.full {
+		n = len(r.events)
+	}
+
+	events := make([]ErrorEvent, 0, n)
+	for i := 1; i <= n; i++ {
+		events = append(events, r.events[(r.next-i+len(r.events))%len(r.events)])
+	}
+	return events
+}
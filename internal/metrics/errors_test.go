@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorRing_Events(t *testing.T) {
+	r := NewErrorRing(3)
+	assert.Empty(t, r.Events())
+
+	for i := 1; i <= 5; i++ {
+		r.Add(assert.AnError, i)
+	}
+
+	events := r.Events()
+	assert.Len(t, events, 3)
+	assert.Equal(t, 5, events[0].Bytes)
+	assert.Equal(t, 4, events[1].Bytes)
+	assert.Equal(t, 3, events[2].Bytes)
+}
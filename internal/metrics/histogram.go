@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// 每个 2 的幂区间内的子桶数量（2^subBucketBits），相对误差不超过 1/subBucketCount
+const (
+	subBucketBits  = 3
+	subBucketCount = 1 << subBucketBits
+)
+
+// bucketCount 覆盖 [0, math.MaxInt64] 所需的桶数量
+const bucketCount = (63-subBucketBits+1)*subBucketCount + subBucketCount
+
+// Histogram 是一个无锁的对数分桶直方图，用于记录非负的 int64 观测值（如纳秒耗时）。
+// 多个协程可以并发调用 Observe 与 Snapshot。
+type Histogram struct {
+	buckets [bucketCount]atomic.Uint64
+	count   atomic.Uint64
+	sum     atomic.Int64
+	max     atomic.Int64
+}
+
+// NewHistogram 创建一个新的直方图
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// bucketIndex 计算观测值所在的桶序号
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	exp := bits.Len64(uint64(v)) - 1
+	sub := int(v>>(exp-subBucketBits)) & (subBucketCount - 1)
+	return (exp-subBucketBits+1)*subBucketCount + sub
+}
+
+// bucketUpperBound 返回桶所能容纳的最大观测值
+func bucketUpperBound(idx int) int64 {
+	if idx < subBucketCount {
+		return int64(idx)
+	}
+	exp := idx/subBucketCount + subBucketBits - 1
+	sub := int64(idx % subBucketCount)
+	shift := exp - subBucketBits
+	upper := uint64(subBucketCount+sub+1)<<shift - 1
+	if upper > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(upper)
+}
+
+// Observe 记录一个观测值，负值按 0 处理
+func (h *Histogram) Observe(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	h.buckets[bucketIndex(v)].Add(1)
+	h.count.Add(1)
+	h.sum.Add(v)
+	for {
+		old := h.max.Load()
+		if v <= old || h.max.CompareAndSwap(old, v) {
+			break
+		}
+	}
+}
+
+// Snapshot 返回直方图当前状态的副本。
+// 由于各字段独立原子读取，在并发写入时快照只保证近似一致。
+func (h *Histogram) Snapshot() Snapshot {
+	s := Snapshot{
+		Count: h.count.Load(),
+		Sum:   h.sum.Load(),
+		Max:   h.max.Load(),
+	}
+	for i := range h.buckets {
+		if c := h.buckets[i].Load(); c > 0 {
+			s.Buckets = append(s.Buckets, Bucket{UpperBound: bucketUpperBound(i), Count: c})
+		}
+	}
+	return s
+}
+
+// Bucket 是快照中的一个非空桶
+type Bucket struct {
+	UpperBound int64  // 桶内观测值的上界（包含）
+	Count      uint64 // 落入该桶的观测次数（非累计）
+}
+
+// Snapshot 是直方图的只读快照
+type Snapshot struct {
+	Count   uint64   // 观测次数
+	Sum     int64    // 观测值之和
+	Max     int64    // 最大观测值
+	Buckets []Bucket // 按上界升序排列的非空桶
+}
+
+// Quantile 返回分位数 q（0 <= q <= 1）的估计值，取所在桶的上界且不超过最大观测值
+func (s Snapshot) Quantile(q float64) int64 {
+	if s.Count == 0 || len(s.Buckets) == 0 {
+		return 0
+	}
+	if q <= 0 {
+		q = 0
+	}
+	if q >= 1 {
+		return s.Max
+	}
+
+	rank := uint64(math.Ceil(q * float64(s.Count)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for _, b := range s.Buckets {
+		seen += b.Count
+		if seen >= rank {
+			if b.UpperBound > s.Max {
+				return s.Max
+			}
+			return b.UpperBound
+		}
+	}
+	return s.Max
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_BucketBounds(t *testing.T) {
+	for _, v := range []int64{0, 1, 7, 8, 9, 15, 16, 17, 100, 1000, 123456789, 1 << 40, 1<<62 + 12345} {
+		idx := bucketIndex(v)
+		assert.LessOrEqualf(t, v, bucketUpperBound(idx), "value %d above upper bound of bucket %d", v, idx)
+		if idx > 0 {
+			assert.Greaterf(t, v, bucketUpperBound(idx-1), "value %d not above previous bucket of %d", v, idx)
+		}
+	}
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	h := NewHistogram()
+	for i := int64(1); i <= 1000; i++ {
+		h.Observe(i)
+	}
+
+	s := h.Snapshot()
+	assert.Equal(t, uint64(1000), s.Count)
+	assert.Equal(t, int64(500500), s.Sum)
+	assert.Equal(t, int64(1000), s.Max)
+
+	p50 := s.Quantile(0.5)
+	assert.InEpsilon(t, 500, p50, 0.125)
+	p99 := s.Quantile(0.99)
+	assert.InEpsilon(t, 990, p99, 0.125)
+	assert.Equal(t, int64(1000), s.Quantile(1))
+}
+
+func TestHistogram_Empty(t *testing.T) {
+	s := NewHistogram().Snapshot()
+	assert.Equal(t, uint64(0), s.Count)
+	assert.Equal(t, int64(0), s.Quantile(0.99))
+}
+
+func TestHistogram_Concurrent(t *testing.T) {
+	h := NewHistogram()
+
+	var wg sync.WaitGroup
+	wg.Add(8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer wg.Done()
+			for j := int64(0); j < 1000; j++ {
+				h.Observe(j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := h.Snapshot()
+	assert.Equal(t, uint64(8000), s.Count)
+	assert.Equal(t, int64(999), s.Max)
+}
@@ -0,0 +1,61 @@
+package poller
+
+import (
+	"hash/maphash"
+	"strconv"
+)
+
+// dedupSeed 重复记录检测使用的哈希种子
+var dedupSeed = maphash.MakeSeed()
+
+// deduper 检测连续重复的记录，只在轮询器协程上使用。
+// 记录按哈希和长度比较，一轮重复从第一条记录写出时开始，持续时间不超过 window。
+type deduper struct {
+	window  int64
+	hash    uint64
+	size    int
+	startAt int64
+	active  bool
+	repeats uint64
+}
+
+// newDeduper 创建重复记录检测器，window <= 0 时返回 nil
+func newDeduper(window int64) *deduper {
+	if window <= 0 {
+		return nil
+	}
+	return &deduper{window: window}
+}
+
+// observe 判断记录是否与上一条写出的记录重复。
+// 重复时返回 true，记录应被丢弃；否则返回上一轮被丢弃的重复次数，并以该记录开始新的一轮。
+func (d *deduper) observe(content []byte, now int64) (bool, uint64) {
+	h := maphash.Bytes(dedupSeed, content)
+	if d.active && h == d.hash && len(content) == d.size && now-d.startAt < d.window {
+		d.repeats++
+		return true, 0
+	}
+
+	repeats := d.repeats
+	d.hash, d.size, d.startAt, d.active, d.repeats = h, len(content), now, true, 0
+	return false, repeats
+}
+
+// end 结束当前一轮重复，返回被丢弃的重复次数，之后相同的记录会重新写出
+func (d *deduper) end() uint64 {
+	repeats := d.repeats
+	d.active, d.repeats = false, 0
+	return repeats
+}
+
+// pending 判断当前一轮是否有尚未报告的重复
+func (d *deduper) pending() bool {
+	return d != nil && d.repeats > 0
+}
+
+// appendRepeatSummary 追加重复摘要记录
+func appendRepeatSummary(dst []byte, repeats uint64) []byte {
+	dst = append(dst, "last message repeated "...)
+	dst = strconv.AppendUint(dst, repeats, 10)
+	return append(dst, " times\n"...)
+}
@@ -4,13 +4,20 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/shengyanli1982/law/frame"
+	"github.com/shengyanli1982/law/internal/metrics"
+	"github.com/shengyanli1982/law/internal/utils"
 	wr "github.com/shengyanli1982/law/internal/writer"
 )
 
+// ErrorPollerStopped 轮询器已停止，无法再执行指令。
+var ErrorPollerStopped = errors.New("poller is stopped")
+
 // Queue 定义了内部轮询器使用的类型化队列接口。
 type Queue[T any] interface {
 	Push(value T)
@@ -24,7 +31,7 @@ type Callback interface {
 
 // Poller 轮询器，负责异步处理队列中的写入请求。
 type Poller struct {
-	queue             Queue[*bytes.Buffer]
+	queue             Queue[wr.Element]
 	writer            *bufio.Writer
 	callback          Callback
 	hasCallback       bool
@@ -33,21 +40,87 @@ type Poller struct {
 	timer             *atomic.Int64
 	heartbeatInterval time.Duration
 	idleTimeout       time.Duration
+	queueDelay        *metrics.Histogram
+	flushDuration     *metrics.Histogram
+	counters          *metrics.Counters
+	errors            *metrics.ErrorRing
+	limiter           *wr.SizeLimiter
+	ensureNewline     bool
+	framer            *frame.Encoder
+	transforms        []wr.Transform
+	tickHooks         []TickHook
+	stopHooks         []TickHook
+	dedup             *deduper
+	frameHeader       []byte
+	repeatSummary     []byte
+	scratch           []byte
+	pending           []int64
+	paused            atomic.Bool
+	ticker            *time.Ticker
+	commands          chan command
+	done              chan struct{}
+}
+
+// command 在轮询器协程上执行的指令。
+type command struct {
+	fn     func() error
+	result chan error
 }
 
 // Config Poller配置。
 type Config struct {
-	Queue             Queue[*bytes.Buffer]
+	Queue             Queue[wr.Element]
 	Writer            *bufio.Writer
 	Callback          Callback
 	BufferPool        *wr.BufferPool
 	Timer             *atomic.Int64
 	HeartbeatInterval time.Duration
 	IdleTimeout       time.Duration
+
+	// QueueDelay 记录从入队到写入底层 io.Writer 的延迟，为 nil 时不统计
+	QueueDelay *metrics.Histogram
+
+	// FlushDuration 记录每次刷新底层 io.Writer 的耗时，为 nil 时不统计
+	FlushDuration *metrics.Histogram
+
+	// Counters 运行时计数器，为 nil 时自动创建
+	Counters *metrics.Counters
+
+	// Errors 最近的写入和刷新失败记录，为 nil 时不记录
+	Errors *metrics.ErrorRing
+
+	// Limiter 延迟编码记录的长度限制器，为 nil 时不限制
+	Limiter *wr.SizeLimiter
+
+	// EnsureNewline 是否为延迟编码的记录补齐结尾的换行
+	EnsureNewline bool
+
+	// Framer 二进制分帧编码器，为 nil 时不分帧
+	Framer *frame.Encoder
+
+	// Transforms 写入前依次执行的转换阶段
+	Transforms []wr.Transform
+
+	// TickHooks 每次心跳时依次执行的钩子
+	TickHooks []TickHook
+
+	// StopHooks 停止时由 RunStopHooks 依次执行的钩子，用于写出最后的摘要
+	StopHooks []TickHook
+
+	// DedupWindow 连续重复记录的合并窗口，小于等于 0 时不合并
+	DedupWindow time.Duration
 }
 
+// TickHook 在每次心跳时于轮询器协程上执行，写入 buff 的内容会作为一条记录写出。
+type TickHook func(now time.Time, buff *bytes.Buffer)
+
 // NewPoller 创建新的轮询器。
 func NewPoller(cfg *Config) *Poller {
+	counters := cfg.Counters
+	if counters == nil {
+		counters = metrics.NewCounters()
+	}
+
 	return &Poller{
 		queue:             cfg.Queue,
 		writer:            cfg.Writer,
@@ -57,12 +130,25 @@ func NewPoller(cfg *Config) *Poller {
 		timer:             cfg.Timer,
 		heartbeatInterval: cfg.HeartbeatInterval,
 		idleTimeout:       cfg.IdleTimeout,
+		queueDelay:        cfg.QueueDelay,
+		flushDuration:     cfg.FlushDuration,
+		counters:          counters,
+		errors:            cfg.Errors,
+		limiter:           cfg.Limiter,
+		ensureNewline:     cfg.EnsureNewline,
+		framer:            cfg.Framer,
+		transforms:        cfg.Transforms,
+		tickHooks:         cfg.TickHooks,
+		stopHooks:         cfg.StopHooks,
+		dedup:             newDeduper(int64(cfg.DedupWindow)),
+		commands:          make(chan command),
+		done:              make(chan struct{}),
 	}
 }
 
 // Run 启动轮询器，处理写入请求和心跳检查。
 func (p *Poller) Run(ctx context.Context, wg *sync.WaitGroup) {
-	ticker := time.NewTicker(p.heartbeatInterval)
+	p.ticker = time.NewTicker(p.heartbeatInterval)
 	var tickCount int64
 
 	now := time.Now().UnixMilli()
@@ -70,35 +156,44 @@ func (p *Poller) Run(ctx context.Context, wg *sync.WaitGroup) {
 	p.executeAt = now
 
 	defer func() {
-		ticker.Stop()
+		p.ticker.Stop()
+		close(p.done)
 		wg.Done()
 	}()
 
 	for {
-		for {
-			element := p.queue.Pop()
-			if element == nil {
-				break
-			}
-			p.executeFunc(element)
+		// 暂停期间记录留在队列中
+		paused := p.paused.Load()
+		if !paused {
+			p.drainQueue()
 		}
 
 		select {
 		case <-ctx.Done():
 			return
 
-		case <-ticker.C:
+		case cmd := <-p.commands:
+			if !p.paused.Load() {
+				p.drainQueue()
+			}
+			cmd.result <- cmd.fn()
+
+		case <-p.ticker.C:
 			tickCount++
+			if paused {
+				continue
+			}
+			p.runTickHooks()
 
-			if tickCount%(int64(time.Second/p.heartbeatInterval)) == 0 {
+			if tickCount%ticksPerSecond(p.heartbeatInterval) == 0 {
 				now = time.Now().UnixMilli()
 				p.timer.Store(now)
 			}
 
-			if p.writer.Buffered() > 0 {
+			if p.writer.Buffered() > 0 || p.dedup.pending() {
 				cachedNow := p.timer.Load()
 				if (cachedNow - p.executeAt) >= p.idleTimeout.Milliseconds() {
-					if err := p.writer.Flush(); err != nil {
+					if err := p.Sync(); err != nil {
 						if p.hasCallback {
 							p.callback.OnWriteFailed(nil, err)
 						}
@@ -110,18 +205,187 @@ func (p *Poller) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// runTickHooks 执行心跳钩子，并把钩子产生的记录写出。
+func (p *Poller) runTickHooks() {
+	p.runHooks(p.tickHooks)
+}
+
+// RunStopHooks 执行停止钩子，并把钩子产生的记录写出。只能在轮询器停止后调用。
+func (p *Poller) RunStopHooks() {
+	p.runHooks(p.stopHooks)
+}
+
+// runHooks 依次执行钩子，并把钩子产生的记录写出。
+func (p *Poller) runHooks(hooks []TickHook) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, hook := range hooks {
+		buff := p.bufferpool.Get()
+		hook(now, buff)
+		if buff.Len() > 0 {
+			p.executeAt = p.timer.Load()
+			p.writeContent(buff.Bytes())
+		}
+		p.bufferpool.Put(buff)
+	}
+}
+
+// drainQueue 写出队列中当前所有的元素。
+func (p *Poller) drainQueue() {
+	for {
+		element := p.queue.Pop()
+		if element.IsEmpty() {
+			break
+		}
+		p.executeFunc(element)
+	}
+}
+
+// Call 在轮询器协程上执行 fn 并返回其结果，执行前会先写出队列中已有的元素。
+// 轮询器已停止时返回 ErrorPollerStopped；ctx 结束时放弃提交或不再等待结果，返回 ctx.Err()，已提交的 fn 仍会执行。
+func (p *Poller) Call(ctx context.Context, fn func() error) error {
+	cmd := command{fn: fn, result: make(chan error, 1)}
+
+	select {
+	case p.commands <- cmd:
+		select {
+		case err := <-cmd.result:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case <-p.done:
+		return ErrorPollerStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // executeFunc 执行写入操作。
-func (p *Poller) executeFunc(buff *bytes.Buffer) {
+func (p *Poller) executeFunc(element wr.Element) {
 	p.executeAt = p.timer.Load()
-	content := buff.Bytes()
 
-	if _, err := p.flushBufferedWriter(content); err != nil {
+	if element.Record != nil {
+		buff := p.bufferpool.Get()
+		err := element.Record.EncodeTo(buff)
+		wr.ReleaseRecord(element.Record)
+		if err != nil {
+			p.counters.FailedRecords.Add(1)
+			p.recordError(err, 0)
+			if p.hasCallback {
+				p.callback.OnWriteFailed(nil, err)
+			}
+			p.bufferpool.Put(buff)
+			return
+		}
+		element.Buffer = buff
+		if p.ensureNewline {
+			wr.EnsureNewline(buff)
+		}
+
+		if p.limiter.Exceeds(buff.Len()) {
+			p.writeOversize(buff.Bytes())
+			p.finishElement(element)
+			return
+		}
+	}
+
+	p.writeContent(element.Buffer.Bytes())
+	p.finishElement(element)
+}
+
+// writeOversize 按限制器的配置处理一条超长的延迟编码记录
+func (p *Poller) writeOversize(content []byte) {
+	err := p.limiter.Apply(content, func(prefix, body, suffix []byte) {
+		p.scratch = append(append(append(p.scratch[:0], prefix...), body...), suffix...)
+		p.writeContent(p.scratch)
+	})
+	if err != nil {
+		p.recordError(err, len(content))
+	}
+}
+
+// writeContent 合并连续重复的记录，并写出其余的记录。
+// 与上一条记录不同的记录会先写出上一轮的重复摘要。
+func (p *Poller) writeContent(content []byte) {
+	if p.dedup != nil && len(content) > 0 {
+		duplicate, repeats := p.dedup.observe(content, utils.Nanotime())
+		if duplicate {
+			p.counters.RepeatedRecords.Add(1)
+			return
+		}
+		p.writeRepeatSummary(repeats)
+	}
+	p.writeRecord(content)
+}
+
+// endRepeatRun 结束当前一轮重复并写出重复摘要。
+func (p *Poller) endRepeatRun() {
+	if p.dedup != nil {
+		p.writeRepeatSummary(p.dedup.end())
+	}
+}
+
+// writeRepeatSummary 在 repeats 大于 0 时写出重复摘要记录。
+func (p *Poller) writeRepeatSummary(repeats uint64) {
+	if repeats == 0 {
+		return
+	}
+	p.repeatSummary = appendRepeatSummary(p.repeatSummary[:0], repeats)
+	p.writeRecord(p.repeatSummary)
+}
+
+// writeRecord 将一条记录经过转换阶段后写入缓冲写入器，并更新计数器。
+func (p *Poller) writeRecord(content []byte) {
+	if len(p.transforms) > 0 {
+		transformed, owned, keep := p.transform(content)
+		if owned != nil {
+			defer p.bufferpool.Put(owned)
+		}
+		if !keep {
+			return
+		}
+		content = transformed
+	}
+
+	write := p.flushBufferedWriter
+	if p.framer != nil {
+		write = p.writeFrame
+	}
+
+	if n, err := write(content); err != nil {
+		p.counters.FailedRecords.Add(1)
+		p.recordError(err, len(content))
 		if p.hasCallback {
 			p.callback.OnWriteFailed(content, err)
 		}
+	} else if n > 0 {
+		p.counters.WrittenRecords.Add(1)
+		p.counters.WrittenBytes.Add(uint64(n))
 	}
+	p.counters.BufferedBytes.Store(int64(p.writer.Buffered()))
+}
+
+// finishElement 统计元素的入队延迟并归还其缓冲区。
+func (p *Poller) finishElement(element wr.Element) {
+	if p.queueDelay != nil && element.EnqueuedAt > 0 {
+		p.trackQueueDelay(element.EnqueuedAt)
+	}
+
+	p.bufferpool.Put(element.Buffer)
+}
 
-	p.bufferpool.Put(buff)
+// trackQueueDelay 记录入队延迟。
+// 内容仍停留在缓冲写入器中时先挂起，等到下一次刷新时再统计。
+func (p *Poller) trackQueueDelay(enqueuedAt int64) {
+	if p.writer.Buffered() == 0 {
+		p.queueDelay.Observe(utils.Nanotime() - enqueuedAt)
+		return
+	}
+	p.pending = append(p.pending, enqueuedAt)
 }
 
 // flushBufferedWriter 刷新缓冲写入器。
@@ -132,7 +396,7 @@ func (p *Poller) flushBufferedWriter(content []byte) (int, error) {
 	}
 
 	if sizeOfContent > p.writer.Available() && p.writer.Buffered() > 0 {
-		if err := p.writer.Flush(); err != nil {
+		if err := p.Flush(); err != nil {
 			return 0, err
 		}
 	}
@@ -140,13 +404,180 @@ func (p *Poller) flushBufferedWriter(content []byte) (int, error) {
 	return p.writer.Write(content)
 }
 
-// CleanQueue 清理队列中的所有内容。
-func (p *Poller) CleanQueue() {
+// transform 依次执行转换阶段，返回转换后的记录、承载该记录的缓冲区（需要归还到缓冲池）以及是否保留记录。
+func (p *Poller) transform(content []byte) ([]byte, *bytes.Buffer, bool) {
+	var owned *bytes.Buffer
+	for _, stage := range p.transforms {
+		out := p.bufferpool.GetWithHint(len(content))
+		keep, err := stage(content, out)
+
+		if err != nil || !keep {
+			if err != nil {
+				p.counters.TransformErrors.Add(1)
+				p.recordError(err, len(content))
+				if p.hasCallback {
+					p.callback.OnWriteFailed(content, err)
+				}
+			} else {
+				p.counters.FilteredRecords.Add(1)
+			}
+			p.bufferpool.Put(out)
+			return nil, owned, false
+		}
+
+		// 没有写入内容的阶段保持记录不变
+		if out.Len() == 0 {
+			p.bufferpool.Put(out)
+			continue
+		}
+		if owned != nil {
+			p.bufferpool.Put(owned)
+		}
+		owned, content = out, out.Bytes()
+	}
+	return content, owned, true
+}
+
+// writeFrame 将一条记录编码为一帧写入缓冲写入器，返回写入的字节数（包含帧头和帧尾）。
+func (p *Poller) writeFrame(content []byte) (int, error) {
+	if len(content) == 0 {
+		return 0, nil
+	}
+
+	// 帧头和帧尾依次追加到同一个切片中
+	header := p.framer.AppendHeader(p.frameHeader[:0], len(content))
+	headerSize := len(header)
+	p.frameHeader = p.framer.AppendTrailer(header, header, content)
+	header, trailer := p.frameHeader[:headerSize], p.frameHeader[headerSize:]
+
+	if size := len(header) + len(content) + len(trailer); size > p.writer.Available() && p.writer.Buffered() > 0 {
+		if err := p.Flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	total := 0
+	for _, part := range [...][]byte{header, content, trailer} {
+		n, err := p.writer.Write(part)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Flush 将缓冲写入器中的内容刷新到底层 io.Writer，并记录刷新耗时与挂起内容的延迟。
+// 只能在轮询器协程上调用，或在轮询器停止后调用。
+func (p *Poller) Flush() error {
+	buffered := p.writer.Buffered()
+	if buffered == 0 {
+		return p.writer.Flush()
+	}
+
+	start := utils.Nanotime()
+	err := p.writer.Flush()
+	end := utils.Nanotime()
+
+	p.counters.Flushes.Add(1)
+	p.counters.BufferedBytes.Store(int64(p.writer.Buffered()))
+	if err != nil {
+		p.counters.FlushErrors.Add(1)
+		p.recordError(err, buffered)
+	} else {
+		p.counters.LastFlushAt.Store(time.Now().UnixNano())
+	}
+
+	if p.flushDuration != nil {
+		p.flushDuration.Observe(end - start)
+	}
+	// 刷新失败的内容不会到达底层 io.Writer，直接丢弃其挂起记录
+	if err == nil {
+		for _, enqueuedAt := range p.pending {
+			p.queueDelay.Observe(end - enqueuedAt)
+		}
+	}
+	p.pending = p.pending[:0]
+
+	return err
+}
+
+// Sync 结束当前一轮重复并写出重复摘要，然后刷新缓冲写入器。
+// 只能在轮询器协程上调用，或在轮询器停止后调用。
+func (p *Poller) Sync() error {
+	p.endRepeatRun()
+	return p.Flush()
+}
+
+// SetBufferedWriter 替换缓冲写入器，调用前应先刷新原来的缓冲写入器。
+// 只能在轮询器协程上调用。
+func (p *Poller) SetBufferedWriter(w *bufio.Writer) {
+	p.writer = w
+	p.counters.BufferedBytes.Store(int64(w.Buffered()))
+}
+
+// ticksPerSecond 返回一秒内的心跳次数，心跳间隔超过一秒时为 1，即每次心跳都更新时钟
+func ticksPerSecond(interval time.Duration) int64 {
+	if n := int64(time.Second / interval); n > 1 {
+		return n
+	}
+	return 1
+}
+
+// SetTiming 修改心跳间隔和闲置超时，并按新的心跳间隔重置定时器。
+// 只能在轮询器协程上调用。
+func (p *Poller) SetTiming(heartbeatInterval, idleTimeout time.Duration) {
+	if heartbeatInterval != p.heartbeatInterval {
+		p.heartbeatInterval = heartbeatInterval
+		p.ticker.Reset(heartbeatInterval)
+	}
+	p.idleTimeout = idleTimeout
+}
+
+// Pause 写出重复摘要并刷新缓冲写入器，之后轮询器不再从队列中取出记录，直到 Resume。
+// 只能在轮询器协程上调用。
+func (p *Poller) Pause() error {
+	err := p.Sync()
+	p.paused.Store(true)
+	return err
+}
+
+// Resume 恢复从队列中取出记录。只能在轮询器协程上调用。
+func (p *Poller) Resume() error {
+	p.paused.Store(false)
+	return nil
+}
+
+// Paused 判断轮询器是否已暂停，可以在任意协程上调用。
+func (p *Poller) Paused() bool {
+	return p.paused.Load()
+}
+
+// DiscardQueue 丢弃队列中的所有元素并归还其资源，返回丢弃的元素数。
+// 只能在轮询器停止后调用。
+func (p *Poller) DiscardQueue() int {
+	discarded := 0
 	for {
-		elem := p.queue.Pop()
-		if elem == nil {
-			break
+		element := p.queue.Pop()
+		if element.IsEmpty() {
+			return discarded
 		}
-		p.executeFunc(elem)
+		if element.Record != nil {
+			wr.ReleaseRecord(element.Record)
+		}
+		p.bufferpool.Put(element.Buffer)
+		discarded++
+	}
+}
+
+// recordError 记录一次失败。
+func (p *Poller) recordError(err error, bytes int) {
+	if p.errors != nil {
+		p.errors.Add(err, bytes)
 	}
 }
+
+// CleanQueue 清理队列中的所有内容。
+func (p *Poller) CleanQueue() {
+	p.drainQueue()
+}
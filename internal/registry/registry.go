@@ -0,0 +1,123 @@
+// Package registry 提供按名称管理写入器的注册表，供 metrics 和 debug 包共用。
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// 错误定义
+var (
+	ErrorNameIsEmpty   = errors.New("writer name is empty")
+	ErrorWriterIsNil   = errors.New("writer is nil")
+	ErrorNameInUse     = errors.New("writer name is already registered")
+	ErrorNotRegistered = errors.New("writer not registered")
+)
+
+// Entry 带名称的注册项
+type Entry[T any] struct {
+	Name  string
+	Value T
+}
+
+// Named 名称到写入器的映射，可以被并发使用
+type Named[T any] struct {
+	mu    sync.RWMutex
+	items map[string]T
+}
+
+// New 创建空的注册表
+func New[T any]() *Named[T] {
+	return &Named[T]{items: make(map[string]T)}
+}
+
+// Register 以名称注册 value，名称为空、value 为 nil 或名称已被使用时返回错误
+func (n *Named[T]) Register(name string, value T) error {
+	if name == "" {
+		return ErrorNameIsEmpty
+	}
+	if isNil(value) {
+		return ErrorWriterIsNil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.items[name]; ok {
+		return ErrorNameInUse
+	}
+	n.items[name] = value
+	return nil
+}
+
+// Unregister 注销指定名称，名称不存在时返回 ErrorNotRegistered
+func (n *Named[T]) Unregister(name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.items[name]; !ok {
+		return ErrorNotRegistered
+	}
+	delete(n.items, name)
+	return nil
+}
+
+// Lookup 按名称查找
+func (n *Named[T]) Lookup(name string) (T, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	value, ok := n.items[name]
+	return value, ok
+}
+
+// Snapshot 按名称顺序返回所有注册项
+func (n *Named[T]) Snapshot() []Entry[T] {
+	n.mu.RLock()
+	entries := make([]Entry[T], 0, len(n.items))
+	for name, value := range n.items {
+		entries = append(entries, Entry[T]{Name: name, Value: value})
+	}
+	n.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Include 将 names 中不在 entries 里的名称通过 lookup 加入 entries，名称相同时保留 entries 中的项，结果按名称排序
+func Include[T any](entries []Entry[T], names []string, lookup func(name string) (T, bool)) []Entry[T] {
+	if len(names) == 0 {
+		return entries
+	}
+
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		seen[entry.Name] = struct{}{}
+	}
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		if value, ok := lookup(name); ok {
+			entries = append(entries, Entry[T]{Name: name, Value: value})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// isNil 判断 value 是否为 nil，包括带类型的 nil 指针
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
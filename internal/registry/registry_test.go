@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type target struct{ id int }
+
+func TestNamed(t *testing.T) {
+	n := New[*target]()
+
+	assert.ErrorIs(t, n.Register("", &target{}), ErrorNameIsEmpty)
+	assert.ErrorIs(t, n.Register("b", nil), ErrorWriterIsNil)
+	assert.Nil(t, n.Register("b", &target{id: 2}))
+	assert.Nil(t, n.Register("a", &target{id: 1}))
+	assert.ErrorIs(t, n.Register("a", &target{id: 3}), ErrorNameInUse)
+
+	value, ok := n.Lookup("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value.id)
+
+	entries := n.Snapshot()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].Name)
+	assert.Equal(t, "b", entries[1].Name)
+
+	assert.Nil(t, n.Unregister("a"))
+	assert.ErrorIs(t, n.Unregister("a"), ErrorNotRegistered)
+	_, ok = n.Lookup("a")
+	assert.False(t, ok)
+}
+
+func TestInclude(t *testing.T) {
+	extra := map[string]*target{"a": {id: 10}, "c": {id: 3}}
+	lookup := func(name string) (*target, bool) {
+		value, ok := extra[name]
+		return value, ok
+	}
+
+	entries := Include([]Entry[*target]{{Name: "a", Value: &target{id: 1}}, {Name: "b", Value: &target{id: 2}}},
+		[]string{"c", "a", "missing"}, lookup)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{entries[0].Name, entries[1].Name, entries[2].Name})
+	// 名称相同时保留原有的项
+	assert.Equal(t, 1, entries[0].Value.id)
+	assert.Equal(t, 3, entries[2].Value.id)
+}
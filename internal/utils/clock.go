@@ -0,0 +1,11 @@
+package utils
+
+import "time"
+
+// processStart 是进程启动时记录的基准时间，携带单调时钟读数
+var processStart = time.Now()
+
+// Nanotime 是一个函数，它返回自进程启动以来经过的单调纳秒数，不受系统时间调整影响。
+func Nanotime() int64 {
+	return int64(time.Since(processStart))
+}
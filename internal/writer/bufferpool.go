@@ -2,11 +2,12 @@ package writer
 
 import (
 	"bytes"
+	"sort"
 	"sync"
 	"sync/atomic"
 )
 
-// 定义不同大小的缓冲区类别
+// 默认的缓冲区大小类别
 const (
 	// 超小缓冲区大小 (<= 128B)
 	tinyBufferSize = 128
@@ -21,84 +22,145 @@ const (
 	largeBufferSize = 32 * 1024
 )
 
-// BufferSizeStats 缓冲区大小统计
+// maxFreeListSize 预算模式下每个类别空闲列表的最大长度
+const maxFreeListSize = 1 << 16
+
+// DefaultSizeClasses 返回默认的缓冲区大小类别
+func DefaultSizeClasses() []int {
+	return []int{tinyBufferSize, smallBufferSize, mediumBufferSize, largeBufferSize}
+}
+
+// NormalizeSizeClasses 是一个函数，它返回去掉非正数和重复值并按升序排列的大小类别。
+// 结果为空时返回默认类别。
+func NormalizeSizeClasses(classes []int) []int {
+	normalized := make([]int, 0, len(classes))
+	for _, c := range classes {
+		if c > 0 {
+			normalized = append(normalized, c)
+		}
+	}
+	if len(normalized) == 0 {
+		return DefaultSizeClasses()
+	}
+
+	sort.Ints(normalized)
+	unique := normalized[:1]
+	for _, c := range normalized[1:] {
+		if c != unique[len(unique)-1] {
+			unique = append(unique, c)
+		}
+	}
+	return unique
+}
+
+// sizeClass 一个大小类别的缓冲区池
+type sizeClass struct {
+	size   int                // 该类别的缓冲区容量
+	pool   *sync.Pool         // 无预算时使用的同步池
+	free   chan *bytes.Buffer // 有预算时使用的空闲列表
+	gets   atomic.Int64       // 获取次数
+	misses atomic.Int64       // 池中无可用缓冲区、需要新建的次数
+}
+
+// BufferSizeStats 缓冲池统计信息
 type BufferSizeStats struct {
-	smallCount  atomic.Int64 // 小缓冲区计数
-	mediumCount atomic.Int64 // 中等缓冲区计数
-	largeCount  atomic.Int64 // 大缓冲区计数
-	overSize    atomic.Int64 // 超大缓冲区计数
-	totalCalls  atomic.Int64 // 总调用次数
+	Classes  []ClassStats // 各大小类别的统计
+	Oversize int64        // 超过最大类别、未经池分配的次数
+	Dropped  int64        // 归还时因超出预算或容量过大而丢弃的次数
+	Retained int64        // 池中保留的字节数，仅在设置预算时统计
+	Budget   int64        // 保留字节数的上限，0 表示不限
+}
+
+// ClassStats 单个大小类别的统计信息
+type ClassStats struct {
+	Size   int   // 类别大小
+	Gets   int64 // 获取次数
+	Misses int64 // 新建次数
 }
 
-// BufferPool 是一个结构体，它包含多个同步池以支持不同大小的缓冲区
+// BufferPool 是一个结构体，它按大小类别管理多个缓冲区池，可以被多个写入器共享。
+//
+// 未设置预算时每个类别使用 sync.Pool，空闲缓冲区可以被 GC 回收；
+// 设置预算后每个类别使用有界空闲列表，所有类别保留的字节数之和不超过预算。
 type BufferPool struct {
-	tinyPool   *sync.Pool      // 超小缓冲区池（<= 128B）
-	smallPool  *sync.Pool      // 小缓冲区池（<= 1KB）
-	mediumPool *sync.Pool      // 中等缓冲区池（<= 8KB）
-	largePool  *sync.Pool      // 大缓冲区池（<= 32KB）
-	stats      BufferSizeStats // 大小统计信息
+	classes  []*sizeClass
+	budget   int64
+	retained atomic.Int64
+	oversize atomic.Int64
+	dropped  atomic.Int64
 }
 
-// NewBufferPool 是一个函数，它创建并返回一个新的 BufferPool
+// NewBufferPool 是一个函数，它使用默认大小类别创建一个不限预算的 BufferPool
 func NewBufferPool() *BufferPool {
-	return &BufferPool{
-		// 创建超小缓冲区池
-		tinyPool: &sync.Pool{
-			New: func() any {
-				return bytes.NewBuffer(make([]byte, 0, tinyBufferSize))
-			},
-		},
-
-		// 创建小缓冲区池
-		smallPool: &sync.Pool{
-			New: func() any {
-				return bytes.NewBuffer(make([]byte, 0, smallBufferSize))
-			},
-		},
-
-		// 创建中等缓冲区池
-		mediumPool: &sync.Pool{
-			New: func() any {
-				return bytes.NewBuffer(make([]byte, 0, mediumBufferSize))
-			},
-		},
-
-		// 创建大缓冲区池
-		largePool: &sync.Pool{
-			New: func() any {
-				return bytes.NewBuffer(make([]byte, 0, largeBufferSize))
-			},
-		},
-
-		// 初始化统计信息
-		stats: BufferSizeStats{},
+	return NewBufferPoolWithClasses(DefaultSizeClasses(), 0)
+}
+
+// NewBufferPoolWithClasses 是一个函数，它使用指定的大小类别和保留字节预算创建 BufferPool。
+// budget <= 0 表示不限预算。
+func NewBufferPoolWithClasses(classes []int, budget int64) *BufferPool {
+	if budget < 0 {
+		budget = 0
 	}
+
+	p := &BufferPool{budget: budget}
+	for _, size := range NormalizeSizeClasses(classes) {
+		c := &sizeClass{size: size}
+		if budget > 0 {
+			n := budget / int64(size)
+			if n > maxFreeListSize {
+				n = maxFreeListSize
+			}
+			c.free = make(chan *bytes.Buffer, n)
+		} else {
+			c.pool = &sync.Pool{
+				New: func() any {
+					c.misses.Add(1)
+					return bytes.NewBuffer(make([]byte, 0, c.size))
+				},
+			}
+		}
+		p.classes = append(p.classes, c)
+	}
+	return p
 }
 
-// Get 是一个方法，它从 BufferPool 获取一个合适大小的缓冲区
-// 如果指定了预期大小，会尝试返回合适容量的缓冲区
+// Get 是一个方法，它从 BufferPool 获取一个最小类别的缓冲区
 func (p *BufferPool) Get() *bytes.Buffer {
 	return p.GetWithHint(0)
 }
 
-// GetWithHint 根据大小提示获取适当的缓冲区
+// GetWithHint 根据大小提示获取容量不小于提示的缓冲区。
+// 超过最大类别的请求直接分配新的缓冲区，不经过池。
 func (p *BufferPool) GetWithHint(sizeHint int) *bytes.Buffer {
-	// 根据大小提示选择合适的缓冲区池
-	if sizeHint <= tinyBufferSize {
-		return p.tinyPool.Get().(*bytes.Buffer)
-	} else if sizeHint <= smallBufferSize {
-		return p.smallPool.Get().(*bytes.Buffer)
-	} else if sizeHint <= mediumBufferSize {
-		return p.mediumPool.Get().(*bytes.Buffer)
-	} else if sizeHint <= largeBufferSize {
-		return p.largePool.Get().(*bytes.Buffer)
-	} else {
-		// 对于超大缓冲区，直接创建新的，不放入池中
-		return bytes.NewBuffer(make([]byte, 0, sizeHint))
+	for _, c := range p.classes {
+		if sizeHint <= c.size {
+			c.gets.Add(1)
+			return p.get(c)
+		}
 	}
+
+	p.oversize.Add(1)
+	return bytes.NewBuffer(make([]byte, 0, sizeHint))
 }
 
-// Put 是一个方法，它将一个缓冲区归还到 BufferPool 中
+// get 从类别中取出一个缓冲区
+func (p *BufferPool) get(c *sizeClass) *bytes.Buffer {
+	if c.pool != nil {
+		return c.pool.Get().(*bytes.Buffer)
+	}
+
+	select {
+	case buff := <-c.free:
+		p.retained.Add(-int64(buff.Cap()))
+		return buff
+	default:
+		c.misses.Add(1)
+		return bytes.NewBuffer(make([]byte, 0, c.size))
+	}
+}
+
+// Put 是一个方法，它将一个缓冲区归还到 BufferPool 中。
+// 缓冲区归入容量不超过其容量的最大类别；容量超过最大类别的缓冲区直接丢弃，由 GC 回收。
 func (p *BufferPool) Put(e *bytes.Buffer) {
 	// 如果缓冲区为空，则直接返回
 	if e == nil {
@@ -108,26 +170,51 @@ func (p *BufferPool) Put(e *bytes.Buffer) {
 	// 重置缓冲区
 	e.Reset()
 
-	// 根据缓冲区容量决定放入哪个池
-	cap := e.Cap()
-	if cap <= tinyBufferSize {
-		p.tinyPool.Put(e)
-	} else if cap <= smallBufferSize {
-		p.smallPool.Put(e)
-	} else if cap <= mediumBufferSize {
-		p.mediumPool.Put(e)
-	} else if cap <= largeBufferSize {
-		p.largePool.Put(e)
-	}
-	// 超大缓冲区不放回池中，让GC回收
+	capacity := e.Cap()
+	if capacity > p.classes[len(p.classes)-1].size {
+		p.dropped.Add(1)
+		return
+	}
+
+	c := p.classes[0]
+	for _, candidate := range p.classes[1:] {
+		if candidate.size > capacity {
+			break
+		}
+		c = candidate
+	}
+
+	if c.pool != nil {
+		c.pool.Put(e)
+		return
+	}
+
+	size := int64(capacity)
+	if p.retained.Add(size) > p.budget {
+		p.retained.Add(-size)
+		p.dropped.Add(1)
+		return
+	}
+
+	select {
+	case c.free <- e:
+	default:
+		p.retained.Add(-size)
+		p.dropped.Add(1)
+	}
 }
 
-// GetStats 返回缓冲池统计信息
-func (p *BufferPool) GetStats() (small, medium, large, oversize, total int64) {
-	small = p.stats.smallCount.Load()
-	medium = p.stats.mediumCount.Load()
-	large = p.stats.largeCount.Load()
-	oversize = p.stats.overSize.Load()
-	total = p.stats.totalCalls.Load()
-	return
+// Stats 返回缓冲池统计信息
+func (p *BufferPool) Stats() BufferSizeStats {
+	stats := BufferSizeStats{
+		Classes:  make([]ClassStats, 0, len(p.classes)),
+		Oversize: p.oversize.Load(),
+		Dropped:  p.dropped.Load(),
+		Retained: p.retained.Load(),
+		Budget:   p.budget,
+	}
+	for _, c := range p.classes {
+		stats.Classes = append(stats.Classes, ClassStats{Size: c.size, Gets: c.gets.Load(), Misses: c.misses.Load()})
+	}
+	return stats
 }
@@ -0,0 +1,57 @@
+package writer
+
+import "bytes"
+
+// Record 定义了延迟编码的记录，由轮询器在写出前编码到缓冲区
+type Record interface {
+	// EncodeTo 将记录编码后追加到 buff
+	EncodeTo(buff *bytes.Buffer) error
+}
+
+// Releaser 定义了可回收的记录，编码完成（无论成功与否）后调用 Release 归还资源
+type Releaser interface {
+	Release()
+}
+
+// ReleaseRecord 是一个函数，它在记录实现了 Releaser 时调用其 Release 方法
+func ReleaseRecord(r Record) {
+	if rel, ok := r.(Releaser); ok {
+		rel.Release()
+	}
+}
+
+// Element 是在队列中流转的写入单元，Buffer 与 Record 二选一
+type Element struct {
+	// Buffer 待写入的数据
+	Buffer *bytes.Buffer
+
+	// Record 待编码的记录
+	Record Record
+
+	// EnqueuedAt 入队时间（单调纳秒），为 0 表示未记录
+	EnqueuedAt int64
+}
+
+// Len 是一个方法，它返回元素携带的数据长度，供有界队列按字节估算容量。
+// 记录在编码前无法得知大小，按 0 计算。
+func (e Element) Len() int {
+	if e.Buffer == nil {
+		return 0
+	}
+	return e.Buffer.Len()
+}
+
+// IsEmpty 是一个方法，它判断元素是否为空（队列为空时 Pop 返回的零值）
+func (e Element) IsEmpty() bool {
+	return e.Buffer == nil && e.Record == nil
+}
+
+// EnsureNewline 是一个函数，它在非空缓冲区不以换行结尾时追加一个换行
+func EnsureNewline(buff *bytes.Buffer) {
+	if n := buff.Len(); n > 0 && buff.Bytes()[n-1] != '\n' {
+		buff.WriteByte('\n')
+	}
+}
+
+// Transform 是在轮询器协程上执行的记录转换阶段，返回 false 表示丢弃记录
+type Transform func(in []byte, out *bytes.Buffer) (keep bool, err error)
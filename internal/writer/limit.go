@@ -0,0 +1,143 @@
+package writer
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shengyanli1982/law/internal/metrics"
+)
+
+// 超长记录的处理方式
+const (
+	OversizeReject   = iota // 拒绝写入
+	OversizeTruncate        // 截断并追加标记
+	OversizeSplit           // 拆分为带编号的分片
+)
+
+// TruncateMarker 截断后追加在记录末尾的标记
+const TruncateMarker = "...[truncated]"
+
+// MinSplitSize 拆分模式允许的最小记录长度，保证分片编号之外还有足够的内容
+const MinSplitSize = 64
+
+// ErrorRecordTooLarge 记录长度超过上限
+var ErrorRecordTooLarge = errors.New("record size exceeds limit")
+
+// EmitFunc 输出一段处理后的记录，三段内容按顺序拼接为一条记录
+type EmitFunc func(prefix, body, suffix []byte)
+
+// SizeLimiter 记录长度限制器，可以被多个协程并发使用
+type SizeLimiter struct {
+	max      int
+	mode     int
+	counters *metrics.Counters
+	notify   func(content []byte, mode int)
+}
+
+// NewSizeLimiter 创建记录长度限制器，max <= 0 时返回 nil，表示不限制。
+// notify 在每次出现超长记录时被调用，可以为 nil。
+func NewSizeLimiter(max, mode int, counters *metrics.Counters, notify func(content []byte, mode int)) *SizeLimiter {
+	if max <= 0 {
+		return nil
+	}
+	if mode == OversizeSplit && max < MinSplitSize {
+		max = MinSplitSize
+	}
+	return &SizeLimiter{max: max, mode: mode, counters: counters, notify: notify}
+}
+
+// Exceeds 判断长度为 size 的记录是否超过上限
+func (l *SizeLimiter) Exceeds(size int) bool {
+	return l != nil && size > l.max
+}
+
+// Apply 处理一条超长记录：拒绝时返回 ErrorRecordTooLarge；截断或拆分时通过 emit 输出结果。
+// 以换行结尾的记录在截断或拆分后，每段输出仍以换行结尾。
+func (l *SizeLimiter) Apply(content []byte, emit EmitFunc) error {
+	if l.notify != nil {
+		l.notify(content, l.mode)
+	}
+
+	switch l.mode {
+	case OversizeTruncate:
+		l.counters.TruncatedRecords.Add(1)
+		l.truncate(content, emit)
+		return nil
+	case OversizeSplit:
+		l.counters.SplitRecords.Add(1)
+		l.split(content, emit)
+		return nil
+	default:
+		l.counters.RejectedRecords.Add(1)
+		return ErrorRecordTooLarge
+	}
+}
+
+// truncate 保留记录开头的内容，并在末尾追加截断标记
+func (l *SizeLimiter) truncate(content []byte, emit EmitFunc) {
+	body, newline := trimNewline(content)
+	suffix := TruncateMarker + newline
+
+	if l.max <= len(suffix) {
+		emit(nil, content[:cutUTF8(content, l.max)], nil)
+		return
+	}
+	emit(nil, body[:cutUTF8(body, l.max-len(suffix))], []byte(suffix))
+}
+
+// split 将记录拆分为以 "[i/n] " 开头的分片，每个分片都不超过上限
+func (l *SizeLimiter) split(content []byte, emit EmitFunc) {
+	body, newline := trimNewline(content)
+
+	// 编号的长度取决于分片数，分片数又取决于编号的长度，迭代到位数稳定为止
+	digits := 1
+	var sizes []int
+	for {
+		payload := l.max - len(newline) - (2*digits + len("[/] "))
+		sizes = sizes[:0]
+		for rest := body; len(rest) > 0; {
+			n := len(rest)
+			if n > payload {
+				n = cutUTF8(rest, payload)
+			}
+			sizes = append(sizes, n)
+			rest = rest[n:]
+		}
+		if d := len(strconv.Itoa(len(sizes))); d > digits {
+			digits = d
+			continue
+		}
+		break
+	}
+
+	header := make([]byte, 0, 2*digits+len("[/] "))
+	for i, n := range sizes {
+		header = append(header[:0], '[')
+		header = strconv.AppendInt(header, int64(i+1), 10)
+		header = append(header, '/')
+		header = strconv.AppendInt(header, int64(len(sizes)), 10)
+		header = append(header, ']', ' ')
+
+		emit(header, body[:n], []byte(newline))
+		body = body[n:]
+	}
+}
+
+// trimNewline 去掉记录末尾的换行，返回去掉后的内容和被去掉的换行
+func trimNewline(content []byte) ([]byte, string) {
+	if n := len(content); n > 0 && content[n-1] == '\n' {
+		return content[:n-1], "\n"
+	}
+	return content, ""
+}
+
+// cutUTF8 返回不超过 n 且不会切断 UTF-8 字符的切分位置，无法避开时返回 n
+func cutUTF8(content []byte, n int) int {
+	for i := n; i > 0 && i > n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(content[i]) {
+			return i
+		}
+	}
+	return n
+}
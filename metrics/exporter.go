@@ -0,0 +1,138 @@
+// Package metrics 将 law.WriteAsyncer 的运行状态导出为 Prometheus 文本格式和 expvar 变量。
+// 该包只依赖标准库，不会为核心模块引入第三方依赖。
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"io"
+	"net/http"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/shengyanli1982/law/internal/registry"
+)
+
+// DefaultNamespace 默认的指标名称前缀
+const DefaultNamespace = "law"
+
+// 错误定义
+var (
+	ErrorWriterNameIsEmpty   = registry.ErrorNameIsEmpty
+	ErrorWriterIsNil         = law.ErrorWriterIsNil
+	ErrorWriterAlreadyExists = law.ErrorNameInUse
+	ErrorWriterNotRegistered = registry.ErrorNotRegistered
+)
+
+// Source 定义了可被导出的统计来源，*law.WriteAsyncer 实现了该接口
+type Source interface {
+	// Stats 返回运行状态快照
+	Stats() law.Stats
+}
+
+// Exporter 指标导出器，按名称管理多个写入器
+type Exporter struct {
+	namespace   string
+	sources     *registry.Named[Source]
+	useRegistry bool
+}
+
+// NewExporter 创建新的导出器，namespace 为空时使用 DefaultNamespace
+func NewExporter(namespace string) *Exporter {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &Exporter{
+		namespace: namespace,
+		sources:   registry.New[Source](),
+	}
+}
+
+// WithRegistry 设置是否同时导出 law 注册表中有名称的写入器（见 law.Config.WithName 和 law.Register），
+// 名称与通过 Register 注册的写入器相同时以后者为准。应在开始导出前设置。
+func (e *Exporter) WithRegistry(enabled bool) *Exporter {
+	e.useRegistry = enabled
+	return e
+}
+
+// Register 以名称注册一个写入器，名称会作为 writer 标签输出
+func (e *Exporter) Register(name string, source Source) error {
+	return e.sources.Register(name, source)
+}
+
+// Unregister 注销指定名称的写入器
+func (e *Exporter) Unregister(name string) error {
+	return e.sources.Unregister(name)
+}
+
+// namedStats 带名称的状态快照
+type namedStats struct {
+	name  string
+	stats law.Stats
+}
+
+// collect 按名称顺序采集所有写入器的状态快照
+func (e *Exporter) collect() []namedStats {
+	entries := e.sources.Snapshot()
+	if e.useRegistry {
+		entries = registry.Include(entries, law.RegisteredNames(), func(name string) (Source, bool) {
+			wa := law.Lookup(name)
+			return wa, wa != nil
+		})
+	}
+	all := make([]namedStats, 0, len(entries))
+	for _, entry := range entries {
+		all = append(all, namedStats{name: entry.Name, stats: entry.Value.Stats()})
+	}
+	return all
+}
+
+// WriteTo 以 Prometheus 文本格式写出所有写入器的指标
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	writePrometheus(cw, e.namespace, e.collect())
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+// ServeHTTP 实现 http.Handler，以 Prometheus 文本格式响应
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = e.WriteTo(w)
+}
+
+// Var 返回一个 expvar.Var，其值为以写入器名称为键的状态快照
+func (e *Exporter) Var() expvar.Var {
+	return expvar.Func(func() any {
+		all := e.collect()
+		vars := make(map[string]any, len(all))
+		for _, s := range all {
+			vars[s.name] = expvarStats(s.stats)
+		}
+		return vars
+	})
+}
+
+// Publish 将 Var 以指定名称发布到 expvar，名称重复时 expvar 会 panic
+func (e *Exporter) Publish(name string) {
+	expvar.Publish(name, e.Var())
+}
+
+// countingWriter 记录写入字节数并保留第一个错误的写入器
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// WriteString 写入字符串，出错后忽略后续写入
+func (c *countingWriter) WriteString(s string) {
+	if c.err != nil {
+		return
+	}
+	n, err := io.WriteString(c.w, s)
+	c.n += int64(n)
+	c.err = err
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticSource struct {
+	stats law.Stats
+}
+
+func (s *staticSource) Stats() law.Stats {
+	return s.stats
+}
+
+func TestExporter_Register(t *testing.T) {
+	e := NewExporter("")
+	src := &staticSource{}
+
+	assert.ErrorIs(t, e.Register("", src), ErrorWriterNameIsEmpty)
+	assert.ErrorIs(t, e.Register("app", nil), ErrorWriterIsNil)
+	assert.Nil(t, e.Register("app", src))
+	assert.ErrorIs(t, e.Register("app", src), ErrorWriterAlreadyExists)
+	assert.Nil(t, e.Unregister("app"))
+	assert.ErrorIs(t, e.Unregister("app"), ErrorWriterNotRegistered)
+}
+
+func TestExporter_Prometheus(t *testing.T) {
+	e := NewExporter("")
+	assert.Nil(t, e.Register("access", &staticSource{stats: law.Stats{Running: true, EnqueuedRecords: 42, QueueLength: 3}}))
+	assert.Nil(t, e.Register(`app"1`, &staticSource{stats: law.Stats{FailedRecords: 7}}))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE law_enqueued_records_total counter\n")
+	assert.Contains(t, body, `law_enqueued_records_total{writer="access"} 42`+"\n")
+	assert.Contains(t, body, `law_queue_length{writer="access"} 3`+"\n")
+	assert.Contains(t, body, `law_up{writer="access"} 1`+"\n")
+	assert.Contains(t, body, `law_failed_records_total{writer="app\"1"} 7`+"\n")
+	assert.Contains(t, body, `law_queue_delay_seconds_bucket{writer="access",le="+Inf"} 0`+"\n")
+	assert.Equal(t, 1, strings.Count(body, "# TYPE law_up gauge"))
+}
+
+func TestExporter_WithWriteAsyncer(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := law.NewWriteAsyncer(buff, law.NewConfig().WithLatencyStats(true))
+
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte("hello"))
+		assert.Nil(t, err)
+	}
+	w.Stop()
+
+	e := NewExporter("svc")
+	assert.Nil(t, e.Register("app", w))
+
+	var out bytes.Buffer
+	n, err := e.WriteTo(&out)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+
+	body := out.String()
+	assert.Contains(t, body, `svc_written_records_total{writer="app"} 5`+"\n")
+	assert.Contains(t, body, `svc_queue_delay_seconds_count{writer="app"} 5`+"\n")
+	assert.Contains(t, body, `svc_queue_delay_seconds_bucket{writer="app",le="10"} 5`+"\n")
+}
+
+func TestExporter_Var(t *testing.T) {
+	e := NewExporter("")
+	assert.Nil(t, e.Register("app", &staticSource{stats: law.Stats{
+		WrittenRecords: 9,
+		LastFlushAt:    time.Unix(1700000000, 0),
+	}}))
+
+	var vars map[string]map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(e.Var().String()), &vars))
+	assert.Equal(t, float64(9), vars["app"]["written_records"])
+	assert.Contains(t, vars["app"], "last_flush_at")
+	assert.Contains(t, vars["app"], "queue_delay")
+}
+
+func TestExporter_WithRegistry(t *testing.T) {
+	w := law.NewWriteAsyncer(bytes.NewBuffer(nil), law.NewConfig().WithName("metrics-named"))
+	defer w.Stop()
+
+	// 默认只导出通过 Register 注册的写入器
+	var buff bytes.Buffer
+	_, _ = NewExporter("").WriteTo(&buff)
+	assert.NotContains(t, buff.String(), "metrics-named")
+
+	e := NewExporter("").WithRegistry(true)
+	assert.ErrorIs(t, e.Register("static", nil), law.ErrorWriterIsNil)
+	assert.Nil(t, e.Register("static", &staticSource{stats: law.Stats{EnqueuedRecords: 5}}))
+	buff.Reset()
+	_, _ = e.WriteTo(&buff)
+	assert.Contains(t, buff.String(), `law_up{writer="metrics-named"} 1`+"\n")
+	assert.Contains(t, buff.String(), `law_enqueued_records_total{writer="static"} 5`+"\n")
+
+	w.Stop()
+	buff.Reset()
+	_, _ = e.WriteTo(&buff)
+	assert.NotContains(t, buff.String(), "metrics-named")
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	law "github.com/shengyanli1982/law"
+)
+
+// expvarStats 将状态快照转换为适合 JSON 输出的结构
+func expvarStats(s law.Stats) map[string]any {
+	vars := map[string]any{
+		"running":            s.Running,
+		"paused":             s.Paused,
+		"queue_length":       s.QueueLength,
+		"buffered_bytes":     s.BufferedBytes,
+		"enqueued_records":   s.EnqueuedRecords,
+		"enqueued_bytes":     s.EnqueuedBytes,
+		"written_records":    s.WrittenRecords,
+		"written_bytes":      s.WrittenBytes,
+		"failed_records":     s.FailedRecords,
+		"flushes":            s.Flushes,
+		"flush_errors":       s.FlushErrors,
+		"rejected_records":   s.RejectedRecords,
+		"truncated_records":  s.TruncatedRecords,
+		"split_records":      s.SplitRecords,
+		"filtered_records":   s.FilteredRecords,
+		"transform_errors":   s.TransformErrors,
+		"suppressed_records": s.SuppressedRecords,
+		"repeated_records":   s.RepeatedRecords,
+		"discarded_records":  s.DiscardedRecords,
+		"queue_delay":        expvarHistogram(s.Latency.QueueDelay),
+		"flush_duration":     expvarHistogram(s.Latency.FlushDuration),
+	}
+	if !s.LastFlushAt.IsZero() {
+		vars["last_flush_at"] = s.LastFlushAt
+	}
+	return vars
+}
+
+// expvarHistogram 将直方图快照转换为以纳秒为单位的摘要
+func expvarHistogram(h law.HistogramSnapshot) map[string]any {
+	return map[string]any{
+		"count":   h.Count,
+		"sum_ns":  int64(h.Sum),
+		"max_ns":  int64(h.Max),
+		"p50_ns":  int64(h.P50),
+		"p90_ns":  int64(h.P90),
+		"p99_ns":  int64(h.P99),
+		"mean_ns": int64(h.Mean()),
+	}
+}
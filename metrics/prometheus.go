@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	law "github.com/shengyanli1982/law"
+)
+
+// DefaultBuckets 直方图导出时使用的固定桶上界（秒），保证每次抓取的桶集合一致
+var DefaultBuckets = []float64{
+	0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+	0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// labelEscaper 转义 Prometheus 标签值中的特殊字符
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricDesc 描述一个计数器或仪表指标
+type metricDesc struct {
+	name  string
+	help  string
+	kind  string
+	value func(law.Stats) float64
+}
+
+// scalarMetrics 所有计数器和仪表指标
+var scalarMetrics = []metricDesc{
+	{"up", "Whether the writer is running (1) or stopped (0).", "gauge", func(s law.Stats) float64 {
+		if s.Running {
+			return 1
+		}
+		return 0
+	}},
+	{"paused", "Whether the writer is paused (1) or not (0).", "gauge", func(s law.Stats) float64 {
+		if s.Paused {
+			return 1
+		}
+		return 0
+	}},
+	{"queue_length", "Records waiting in the queue, -1 if the queue cannot report its length.", "gauge", func(s law.Stats) float64 { return float64(s.QueueLength) }},
+	{"buffered_bytes", "Bytes held in the buffered writer and not yet flushed.", "gauge", func(s law.Stats) float64 { return float64(s.BufferedBytes) }},
+	{"last_flush_timestamp_seconds", "Unix time of the last successful flush, 0 if never flushed.", "gauge", func(s law.Stats) float64 {
+		if s.LastFlushAt.IsZero() {
+			return 0
+		}
+		return float64(s.LastFlushAt.UnixNano()) / 1e9
+	}},
+	{"enqueued_records_total", "Records accepted by Write.", "counter", func(s law.Stats) float64 { return float64(s.EnqueuedRecords) }},
+	{"enqueued_bytes_total", "Bytes accepted by Write.", "counter", func(s law.Stats) float64 { return float64(s.EnqueuedBytes) }},
+	{"written_records_total", "Records written to the buffered writer.", "counter", func(s law.Stats) float64 { return float64(s.WrittenRecords) }},
+	{"written_bytes_total", "Bytes written to the buffered writer.", "counter", func(s law.Stats) float64 { return float64(s.WrittenBytes) }},
+	{"failed_records_total", "Records that failed to be written.", "counter", func(s law.Stats) float64 { return float64(s.FailedRecords) }},
+	{"flushes_total", "Flushes of the underlying io.Writer.", "counter", func(s law.Stats) float64 { return float64(s.Flushes) }},
+	{"flush_errors_total", "Flushes of the underlying io.Writer that failed.", "counter", func(s law.Stats) float64 { return float64(s.FlushErrors) }},
+	{"rejected_records_total", "Records rejected for exceeding the maximum record size.", "counter", func(s law.Stats) float64 { return float64(s.RejectedRecords) }},
+	{"truncated_records_total", "Records truncated for exceeding the maximum record size.", "counter", func(s law.Stats) float64 { return float64(s.TruncatedRecords) }},
+	{"split_records_total", "Records split for exceeding the maximum record size.", "counter", func(s law.Stats) float64 { return float64(s.SplitRecords) }},
+	{"filtered_records_total", "Records dropped by a transform stage.", "counter", func(s law.Stats) float64 { return float64(s.FilteredRecords) }},
+	{"transform_errors_total", "Transform stages that returned an error.", "counter", func(s law.Stats) float64 { return float64(s.TransformErrors) }},
+	{"suppressed_records_total", "Records dropped by the sampler.", "counter", func(s law.Stats) float64 { return float64(s.SuppressedRecords) }},
+	{"repeated_records_total", "Duplicate records collapsed into a repeat summary.", "counter", func(s law.Stats) float64 { return float64(s.RepeatedRecords) }},
+	{"discarded_records_total", "Records discarded by Stop while paused.", "counter", func(s law.Stats) float64 { return float64(s.DiscardedRecords) }},
+}
+
+// histogramDesc 描述一个直方图指标
+type histogramDesc struct {
+	name  string
+	help  string
+	value func(law.Stats) law.HistogramSnapshot
+}
+
+// histogramMetrics 所有直方图指标
+var histogramMetrics = []histogramDesc{
+	{"queue_delay_seconds", "Delay between Write and the record reaching the io.Writer.", func(s law.Stats) law.HistogramSnapshot { return s.Latency.QueueDelay }},
+	{"flush_duration_seconds", "Duration of each flush of the underlying io.Writer.", func(s law.Stats) law.HistogramSnapshot { return s.Latency.FlushDuration }},
+}
+
+// writePrometheus 以 Prometheus 文本格式写出指标
+func writePrometheus(w *countingWriter, namespace string, all []namedStats) {
+	if len(all) == 0 {
+		return
+	}
+
+	for _, m := range scalarMetrics {
+		name := namespace + "_" + m.name
+		writeHeader(w, name, m.help, m.kind)
+		for _, s := range all {
+			writeSample(w, name, s.name, "", m.value(s.stats))
+		}
+	}
+
+	for _, m := range histogramMetrics {
+		name := namespace + "_" + m.name
+		writeHeader(w, name, m.help, "histogram")
+		for _, s := range all {
+			writeHistogram(w, name, s.name, m.value(s.stats))
+		}
+	}
+}
+
+// writeHeader 写出指标的 HELP 和 TYPE 行
+func writeHeader(w *countingWriter, name, help, kind string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeSample 写出一个样本行，le 为空时不输出 le 标签
+func writeSample(w *countingWriter, name, writer, le string, value float64) {
+	w.WriteString(name)
+	w.WriteString(`{writer="`)
+	w.WriteString(labelEscaper.Replace(writer))
+	if le != "" {
+		w.WriteString(`",le="`)
+		w.WriteString(le)
+	}
+	w.WriteString(`"} `)
+	w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.WriteString("\n")
+}
+
+// writeHistogram 将对数分桶直方图折算到 DefaultBuckets 后写出。
+// 内部桶的上界可能略大于导出桶的上界，折算结果是近似值。
+func writeHistogram(w *countingWriter, name, writer string, h law.HistogramSnapshot) {
+	var cumulative uint64
+	idx := 0
+	for _, bound := range DefaultBuckets {
+		limit := time.Duration(bound * float64(time.Second))
+		for idx < len(h.Buckets) && h.Buckets[idx].UpperBound <= limit {
+			cumulative += h.Buckets[idx].Count
+			idx++
+		}
+		writeSample(w, name+"_bucket", writer, strconv.FormatFloat(bound, 'g', -1, 64), float64(cumulative))
+	}
+	writeSample(w, name+"_bucket", writer, "+Inf", float64(h.Count))
+	writeSample(w, name+"_sum", writer, "", h.Sum.Seconds())
+	writeSample(w, name+"_count", writer, "", float64(h.Count))
+}
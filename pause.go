@@ -0,0 +1,48 @@
+package law
+
+// StopPolicy 暂停期间调用 Stop 时对队列中记录的处理方式
+type StopPolicy int
+
+const (
+	// StopDrain 写出队列中的所有记录后停止
+	StopDrain StopPolicy = iota
+
+	// StopDiscard 丢弃队列中的记录，计入 Stats().DiscardedRecords
+	StopDiscard
+)
+
+// String 返回处理方式的名称
+func (p StopPolicy) String() string {
+	switch p {
+	case StopDrain:
+		return "drain"
+	case StopDiscard:
+		return "discard"
+	default:
+		return "unknown"
+	}
+}
+
+// Pause 暂停写出：队列中已有的记录写出并刷新后，轮询器不再向底层 io.Writer 写入，直到 Resume。
+// 暂停期间 Write 仍然入队（受队列自身的容量限制），Flush 不会写出队列中的记录，采样摘要和闲置刷新也会暂停；
+// SetWriter 和 Reopen 可以正常使用，例如在迁移日志卷时切换到新的文件。
+// 暂停期间调用 Stop 时按 WithPausedStopPolicy 处理队列中的记录。
+func (wa *WriteAsyncer) Pause() error {
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+	return wa.call(wa.poller.Pause)
+}
+
+// Resume 恢复写出，暂停期间入队的记录按顺序写出
+func (wa *WriteAsyncer) Resume() error {
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+	return wa.call(wa.poller.Resume)
+}
+
+// Paused 判断写入器是否已暂停
+func (wa *WriteAsyncer) Paused() bool {
+	return wa.poller.Paused()
+}
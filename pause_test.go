@@ -0,0 +1,67 @@
+package law
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_PauseResume(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().WithHeartbeatInterval(5 * time.Millisecond).WithIdleTimeout(5 * time.Millisecond)
+	w := NewWriteAsyncer(buff, conf)
+
+	// 暂停前入队的记录先写出
+	_, _ = w.Write([]byte("before\n"))
+	assert.Nil(t, w.Pause())
+	assert.True(t, w.Paused())
+	assert.True(t, w.Stats().Paused)
+	assert.Equal(t, uint64(1), w.Stats().WrittenRecords)
+
+	_, err := w.Write([]byte("during\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Flush())
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, uint64(1), w.Stats().WrittenRecords)
+	assert.Equal(t, 1, w.Stats().QueueLength)
+
+	assert.Nil(t, w.Resume())
+	assert.False(t, w.Paused())
+	assert.Nil(t, w.Flush())
+	assert.Equal(t, uint64(2), w.Stats().WrittenRecords)
+
+	w.Stop()
+	assert.Equal(t, "before\nduring\n", buff.String())
+	assert.ErrorIs(t, w.Pause(), ErrorWriteAsyncerIsClosed)
+}
+
+func TestWriteAsyncer_StopWhilePaused(t *testing.T) {
+	t.Run("drain", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		assert.Nil(t, w.Pause())
+		_, _ = w.Write([]byte("queued\n"))
+		w.Stop()
+
+		assert.Equal(t, "queued\n", buff.String())
+		assert.Equal(t, uint64(0), w.Stats().DiscardedRecords)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, NewConfig().WithPausedStopPolicy(StopDiscard))
+		_, _ = w.Write([]byte("written\n"))
+		assert.Nil(t, w.Pause())
+		_, _ = w.Write([]byte("queued\n"))
+		_ = w.WriteRecord(RecordFunc(func(b *bytes.Buffer) error {
+			b.WriteString("record\n")
+			return nil
+		}))
+		w.Stop()
+
+		assert.Equal(t, "written\n", buff.String())
+		assert.Equal(t, uint64(2), w.Stats().DiscardedRecords)
+	})
+}
@@ -0,0 +1,21 @@
+package law
+
+import (
+	wr "github.com/shengyanli1982/law/internal/writer"
+)
+
+// bufferQueue 将用户提供的 Queue 适配为内部的元素队列。
+// 用户队列只能承载 *bytes.Buffer，入队时间等元数据会在适配时丢失。
+type bufferQueue struct {
+	queue Queue
+}
+
+// Push 将元素携带的缓冲区推入用户队列
+func (q *bufferQueue) Push(e wr.Element) {
+	q.queue.Push(e.Buffer)
+}
+
+// Pop 从用户队列取出缓冲区并包装为元素
+func (q *bufferQueue) Pop() wr.Element {
+	return wr.Element{Buffer: q.queue.Pop()}
+}
@@ -0,0 +1,154 @@
+package law
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// 错误定义
+var (
+	ErrorConfigIsNil             = errors.New("config is nil")
+	ErrorConfigNotReconfigurable = errors.New("config change cannot be applied to a running writer")
+)
+
+// Reconfigure 在运行中应用新的配置，通常基于 Config 返回的副本修改：
+//
+//	conf := w.Config().WithIdleTimeout(time.Second).WithBufferSize(64 * 1024)
+//	err := w.Reconfigure(conf)
+//
+// 可以在运行中修改的字段为缓冲区大小、心跳间隔、闲置超时、WithPausedStopPolicy 和 WithCloseReplacedWriter。
+// 修改在轮询器协程上执行：先写出队列中已有的记录并刷新，缓冲区大小变化时重新创建缓冲写入器，心跳间隔变化时重置定时器。
+// 其他字段（例如队列、回调、转换阶段）与当前配置不同时不做任何修改，返回 ErrorConfigNotReconfigurable 并指明字段；
+// 刷新失败时同样不做修改并返回刷新错误。无效的值与 NewWriteAsyncer 一样被替换为默认值。
+func (wa *WriteAsyncer) Reconfigure(conf *Config) error {
+	if conf == nil {
+		return ErrorConfigIsNil
+	}
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+
+	next := isConfigValid(conf.clone())
+	if field := diffStatic(wa.conf(), next); field != "" {
+		return fmt.Errorf("%w: %s", ErrorConfigNotReconfigurable, field)
+	}
+
+	return wa.call(func() error {
+		if err := wa.poller.Sync(); err != nil {
+			return err
+		}
+
+		current := wa.conf()
+		if next.buffSize != current.buffSize {
+			var target io.Writer = wa.writer
+			if wa.encrypter != nil {
+				target = wa.encrypter
+			}
+			wa.bufferedWriter = bufio.NewWriterSize(target, next.buffSize)
+			wa.poller.SetBufferedWriter(wa.bufferedWriter)
+		}
+		wa.poller.SetTiming(next.heartbeatInterval, next.idleTimeout)
+
+		// 只保存可以在运行中修改的字段，其余字段保持写入器实际使用的值
+		applied := current.clone()
+		applied.buffSize = next.buffSize
+		applied.heartbeatInterval = next.heartbeatInterval
+		applied.idleTimeout = next.idleTimeout
+		applied.pausedStop = next.pausedStop
+		applied.closeReplaced = next.closeReplaced
+		wa.config.Store(applied)
+		return nil
+	})
+}
+
+// diffStatic 返回两个配置中第一个不能在运行中修改且不相同的字段名，全部相同时返回空字符串
+func diffStatic(a, b *Config) string {
+	fields := []struct {
+		name  string
+		equal bool
+	}{
+		{"callback", sameValue(a.callback, b.callback)},
+		{"queue", sameValue(a.queue, b.queue)},
+		{"latencyStats", a.latencyStats == b.latencyStats},
+		{"sizeClasses", sameInts(a.sizeClasses, b.sizeClasses)},
+		{"poolBudget", a.poolBudget == b.poolBudget},
+		{"bufferPool", a.bufferPool == b.bufferPool},
+		{"maxRecordSize", a.maxRecordSize == b.maxRecordSize},
+		{"oversizeMode", a.oversizeMode == b.oversizeMode},
+		{"ensureNewline", a.ensureNewline == b.ensureNewline},
+		{"framing", a.framing == b.framing},
+		{"frameSeqStart", a.frameSeqStart == b.frameSeqStart},
+		{"transforms", sameTransforms(a.transforms, b.transforms)},
+		{"sampler", a.sampler == b.sampler},
+		{"dedupWindow", a.dedupWindow == b.dedupWindow},
+		{"encryptionKeys", sameValue(a.encryptionKeys, b.encryptionKeys)},
+		{"audit", a.audit == b.audit},
+		{"name", a.name == b.name},
+	}
+	for _, f := range fields {
+		if !f.equal {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// sameInts 判断两个整数切片的元素是否相同，nil 与空切片视为相同
+func sameInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTransforms 判断两组转换阶段是否相同
+func sameTransforms(a, b []Transform) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameValue(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue 判断两个值是否相同。函数按函数值本身比较，同一份代码生成的不同闭包或方法值视为不同；
+// 动态类型不可比较的值（例如包含 map 或切片的结构体）按内容比较。
+func sameValue(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+	if va.Kind() == reflect.Func {
+		return funcIdentity(va) == funcIdentity(vb)
+	}
+	if !va.Comparable() || !vb.Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+// funcIdentity 返回函数值指向的闭包对象的地址。函数值的副本（例如 Config 返回的副本中的转换阶段）地址相同，
+// 而每次创建的闭包或方法值都有自己的地址，因此可以区分代码相同、捕获的状态不同的函数。
+func funcIdentity(v reflect.Value) unsafe.Pointer {
+	if v.IsNil() {
+		return nil
+	}
+	holder := reflect.New(v.Type())
+	holder.Elem().Set(v)
+	return *(*unsafe.Pointer)(holder.UnsafePointer())
+}
@@ -0,0 +1,115 @@
+package law
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/law/crypt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_Reconfigure(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, NewConfig().WithBufferSize(16).WithIdleTimeout(time.Hour))
+
+	_, _ = w.Write([]byte("before\n"))
+	conf := w.Config().
+		WithBufferSize(4096).
+		WithHeartbeatInterval(5 * time.Millisecond).
+		WithIdleTimeout(5 * time.Millisecond)
+	assert.Nil(t, w.Reconfigure(conf))
+
+	// 修改前入队的记录已经写出
+	assert.Equal(t, "before\n", buff.String())
+	current := w.Config()
+	assert.Equal(t, 4096, current.BufferSize())
+	assert.Equal(t, 5*time.Millisecond, current.HeartbeatInterval())
+	assert.Equal(t, 5*time.Millisecond, current.IdleTimeout())
+
+	// 新的闲置超时生效，轮询器的时钟每秒更新一次
+	_, _ = w.Write([]byte("after\n"))
+	assert.Eventually(t, func() bool {
+		return w.Stats().Flushes == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	w.Stop()
+	assert.Equal(t, "before\nafter\n", buff.String())
+	assert.ErrorIs(t, w.Reconfigure(conf), ErrorWriteAsyncerIsClosed)
+}
+
+func TestWriteAsyncer_ReconfigureRejected(t *testing.T) {
+	w := NewWriteAsyncer(&bytes.Buffer{}, nil)
+	defer w.Stop()
+
+	assert.ErrorIs(t, w.Reconfigure(nil), ErrorConfigIsNil)
+
+	err := w.Reconfigure(w.Config().WithQueue(&sliceQueue{}).WithBufferSize(1))
+	assert.ErrorIs(t, err, ErrorConfigNotReconfigurable)
+	assert.Contains(t, err.Error(), "queue")
+	assert.Equal(t, DefaultBufferSize, w.Config().BufferSize())
+
+	err = w.Reconfigure(w.Config().WithTransforms(PrefixTransform(func(*bytes.Buffer) {})))
+	assert.ErrorIs(t, err, ErrorConfigNotReconfigurable)
+	assert.Contains(t, err.Error(), "transforms")
+
+	assert.Nil(t, w.Reconfigure(w.Config().WithPausedStopPolicy(StopDiscard)))
+	assert.Equal(t, StopDiscard, w.Config().PausedStopPolicy())
+}
+
+// mapCallback 动态类型不可比较的回调
+type mapCallback struct {
+	failed map[string]int
+}
+
+func (c mapCallback) OnWriteFailed([]byte, error) {}
+
+func TestWriteAsyncer_ReconfigureNonComparableCallback(t *testing.T) {
+	w := NewWriteAsyncer(&bytes.Buffer{}, NewConfig().WithCallback(mapCallback{failed: map[string]int{}}))
+	defer w.Stop()
+
+	// 回调没有变化，不能被误判为不可修改的字段
+	assert.Nil(t, w.Reconfigure(w.Config().WithIdleTimeout(time.Second)))
+	assert.Equal(t, time.Second, w.Config().IdleTimeout())
+
+	err := w.Reconfigure(w.Config().WithCallback(mapCallback{failed: map[string]int{"x": 1}}))
+	assert.ErrorIs(t, err, ErrorConfigNotReconfigurable)
+	assert.Contains(t, err.Error(), "callback")
+}
+
+// maskTransform 每次调用都返回一个新的闭包，代码相同，捕获的掩码不同
+func maskTransform(mask string) Transform {
+	return func(in []byte, out *bytes.Buffer) (bool, error) {
+		out.WriteString(mask)
+		out.WriteByte('\n')
+		return true, nil
+	}
+}
+
+func TestWriteAsyncer_ReconfigureDistinctClosures(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	key := bytes.Repeat([]byte{1}, 32)
+	conf := NewConfig().WithTransforms(maskTransform("AAA")).WithEncryption(crypt.StaticKey(1, key))
+	w := NewWriteAsyncer(buff, conf)
+
+	// 同一份代码生成的不同闭包不能被当作相同的转换阶段
+	err := w.Reconfigure(NewConfig().WithTransforms(maskTransform("BBB")).WithEncryption(w.Config().EncryptionKeys()))
+	assert.ErrorIs(t, err, ErrorConfigNotReconfigurable)
+	assert.Contains(t, err.Error(), "transforms")
+
+	err = w.Reconfigure(w.Config().WithEncryption(crypt.StaticKey(1, key)))
+	assert.ErrorIs(t, err, ErrorConfigNotReconfigurable)
+	assert.Contains(t, err.Error(), "encryptionKeys")
+
+	// Config 返回的副本中的函数值与当前配置相同，可以修改其他字段
+	assert.Nil(t, w.Reconfigure(w.Config().WithIdleTimeout(time.Minute)))
+	assert.Equal(t, time.Minute, w.Config().IdleTimeout())
+
+	_, _ = w.Write([]byte("secret\n"))
+	w.Stop()
+	lookup := func(uint32) ([]byte, error) { return key, nil }
+	plain, err := io.ReadAll(crypt.NewReader(bytes.NewReader(buff.Bytes()), lookup))
+	assert.Nil(t, err)
+	assert.Equal(t, "AAA\n", string(plain))
+}
@@ -0,0 +1,101 @@
+package law
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type accessRecord struct {
+	method   string
+	status   int
+	released *atomic.Int64
+}
+
+func (r *accessRecord) EncodeTo(buff *bytes.Buffer) error {
+	buff.WriteString(r.method)
+	buff.WriteByte(' ')
+	buff.WriteString(strconv.Itoa(r.status))
+	buff.WriteByte('\n')
+	return nil
+}
+
+func (r *accessRecord) Release() {
+	r.released.Add(1)
+}
+
+type encodeFailedCallback struct {
+	failed atomic.Int64
+}
+
+func (c *encodeFailedCallback) OnWriteFailed(content []byte, reason error) {
+	if content == nil && errors.Is(reason, errorWriteFailed) {
+		c.failed.Add(1)
+	}
+}
+
+func TestWriteAsyncer_WriteRecord(t *testing.T) {
+	t.Run("records are encoded in order with raw writes", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+
+		var released atomic.Int64
+		assert.Nil(t, w.WriteRecord(&accessRecord{method: "GET", status: 200, released: &released}))
+		_, err := w.Write([]byte("raw\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, w.WriteRecord(RecordFunc(func(buff *bytes.Buffer) error {
+			buff.WriteString("func\n")
+			return nil
+		})))
+		w.Stop()
+
+		assert.Equal(t, "GET 200\nraw\nfunc\n", buff.String())
+		assert.Equal(t, int64(1), released.Load())
+		assert.Equal(t, uint64(3), w.Stats().WrittenRecords)
+	})
+
+	t.Run("encode failure is reported through callback", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		cb := &encodeFailedCallback{}
+		w := NewWriteAsyncer(buff, NewConfig().WithCallback(cb))
+
+		assert.Nil(t, w.WriteRecord(RecordFunc(func(*bytes.Buffer) error {
+			return errorWriteFailed
+		})))
+		w.Stop()
+
+		assert.Equal(t, int64(1), cb.failed.Load())
+		assert.Equal(t, uint64(1), w.Stats().FailedRecords)
+		assert.Equal(t, 0, buff.Len())
+	})
+
+	t.Run("custom queue encodes on caller goroutine", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, NewConfig().WithQueue(&sliceQueue{}))
+
+		var released atomic.Int64
+		assert.Nil(t, w.WriteRecord(&accessRecord{method: "POST", status: 201, released: &released}))
+		assert.Equal(t, int64(1), released.Load())
+
+		err := w.WriteRecord(RecordFunc(func(*bytes.Buffer) error { return errorWriteFailed }))
+		assert.ErrorIs(t, err, errorWriteFailed)
+		w.Stop()
+
+		assert.Equal(t, "POST 201\n", buff.String())
+	})
+
+	t.Run("nil and closed", func(t *testing.T) {
+		w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+		assert.ErrorIs(t, w.WriteRecord(nil), ErrorWriteContentIsNil)
+		w.Stop()
+
+		var released atomic.Int64
+		err := w.WriteRecord(&accessRecord{released: &released})
+		assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+		assert.Equal(t, int64(1), released.Load())
+	})
+}
@@ -0,0 +1,77 @@
+package law
+
+import (
+	wr "github.com/shengyanli1982/law/internal/writer"
+)
+
+// OversizeMode 超过最大长度的记录的处理方式
+type OversizeMode int
+
+const (
+	// OversizeReject 拒绝写入，Write 返回 ErrorRecordTooLarge
+	OversizeReject OversizeMode = wr.OversizeReject
+
+	// OversizeTruncate 保留记录开头的内容，并在末尾追加 TruncateMarker
+	OversizeTruncate OversizeMode = wr.OversizeTruncate
+
+	// OversizeSplit 将记录拆分为以 "[i/n] " 开头的多条记录
+	OversizeSplit OversizeMode = wr.OversizeSplit
+)
+
+// TruncateMarker 截断后追加在记录末尾的标记
+const TruncateMarker = wr.TruncateMarker
+
+// String 返回处理方式的名称
+func (m OversizeMode) String() string {
+	switch m {
+	case OversizeReject:
+		return "reject"
+	case OversizeTruncate:
+		return "truncate"
+	case OversizeSplit:
+		return "split"
+	default:
+		return "unknown"
+	}
+}
+
+// OversizeCallback 是可选的回调接口。通过 WithCallback 设置的回调同时实现该接口时，
+// 每次出现超过最大长度的记录都会被通知。
+//
+// 通过 Write 和 WriteOwned 写入的记录在调用方协程上通知，延迟编码的记录在轮询器协程上通知。
+// content 是原始记录，只在回调期间有效。
+type OversizeCallback interface {
+	// OnRecordOversize 当记录超过最大长度时被调用
+	OnRecordOversize(content []byte, mode OversizeMode)
+}
+
+// newSizeLimiter 根据配置创建记录长度限制器，未设置最大长度时返回 nil
+func newSizeLimiter(conf *Config, wa *WriteAsyncer) *wr.SizeLimiter {
+	var notify func([]byte, int)
+	if cb, ok := conf.callback.(OversizeCallback); ok {
+		notify = func(content []byte, mode int) {
+			cb.OnRecordOversize(content, OversizeMode(mode))
+		}
+	}
+	return wr.NewSizeLimiter(conf.maxRecordSize, int(conf.oversizeMode), wa.counters, notify)
+}
+
+// writeOversize 按配置处理一条超长记录，截断或拆分的结果复制到缓冲池的缓冲区后入队
+func (wa *WriteAsyncer) writeOversize(p []byte) (int, error) {
+	content := p
+	if wa.conf().ensureNewline && p[len(p)-1] != '\n' {
+		content = append(append(make([]byte, 0, len(p)+1), p...), '\n')
+	}
+
+	err := wa.limiter.Apply(content, func(prefix, body, suffix []byte) {
+		buff := wa.bufferpool.GetWithHint(len(prefix) + len(body) + len(suffix))
+		buff.Write(prefix)
+		buff.Write(body)
+		buff.Write(suffix)
+		wa.enqueueBuffer(buff)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
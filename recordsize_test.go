@@ -0,0 +1,123 @@
+package law
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// oversizeCallback 记录超长通知的回调
+type oversizeCallback struct {
+	mu    sync.Mutex
+	sizes []int
+	modes []OversizeMode
+}
+
+func (c *oversizeCallback) OnWriteFailed([]byte, error) {}
+
+func (c *oversizeCallback) OnRecordOversize(content []byte, mode OversizeMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sizes = append(c.sizes, len(content))
+	c.modes = append(c.modes, mode)
+}
+
+func TestWriteAsyncer_MaxRecordSize(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		cb := &oversizeCallback{}
+		w := NewWriteAsyncer(buff, NewConfig().WithMaxRecordSize(16, OversizeReject).WithCallback(cb))
+
+		n, err := w.Write([]byte(strings.Repeat("a", 17)))
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrorRecordTooLarge)
+
+		n, err = w.Write([]byte(strings.Repeat("b", 16)))
+		assert.Equal(t, 16, n)
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, strings.Repeat("b", 16), buff.String())
+		assert.Equal(t, uint64(1), w.Stats().RejectedRecords)
+		assert.Equal(t, []int{17}, cb.sizes)
+		assert.Equal(t, []OversizeMode{OversizeReject}, cb.modes)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, NewConfig().WithMaxRecordSize(32, OversizeTruncate))
+
+		content := strings.Repeat("a", 100) + "\n"
+		n, err := w.Write([]byte(content))
+		assert.Equal(t, len(content), n)
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, strings.Repeat("a", 32-len(TruncateMarker)-1)+TruncateMarker+"\n", buff.String())
+		assert.Equal(t, uint64(1), w.Stats().TruncatedRecords)
+	})
+
+	t.Run("truncate keeps utf8 runes whole", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, NewConfig().WithMaxRecordSize(32, OversizeTruncate))
+
+		_, _ = w.Write([]byte(strings.Repeat("日", 20)))
+		w.Stop()
+
+		assert.True(t, utf8.Valid(buff.Bytes()))
+		assert.LessOrEqual(t, buff.Len(), 32)
+		assert.True(t, strings.HasSuffix(buff.String(), TruncateMarker))
+	})
+
+	t.Run("split", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 4096))
+		w := NewWriteAsyncer(buff, NewConfig().WithMaxRecordSize(64, OversizeSplit))
+
+		body := strings.Repeat("0123456789", 100)
+		n, err := w.WriteOwned(bytes.NewBufferString(body + "\n"))
+		assert.Equal(t, len(body)+1, n)
+		assert.Nil(t, err)
+		w.Stop()
+
+		lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+		assert.Len(t, lines, 19)
+		var joined strings.Builder
+		for i, line := range lines {
+			assert.LessOrEqual(t, len(line)+1, 64)
+			prefix := "[" + strconv.Itoa(i+1) + "/19] "
+			assert.True(t, strings.HasPrefix(line, prefix), line)
+			joined.WriteString(strings.TrimPrefix(line, prefix))
+		}
+		assert.Equal(t, body, joined.String())
+		assert.Equal(t, uint64(1), w.Stats().SplitRecords)
+	})
+
+	t.Run("deferred record", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		cb := &oversizeCallback{}
+		w := NewWriteAsyncer(buff, NewConfig().WithMaxRecordSize(64, OversizeSplit).WithCallback(cb))
+
+		err := w.WriteRecord(RecordFunc(func(b *bytes.Buffer) error {
+			b.WriteString(strings.Repeat("x", 100))
+			return nil
+		}))
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, "[1/2] "+strings.Repeat("x", 58)+"[2/2] "+strings.Repeat("x", 42), buff.String())
+		assert.Equal(t, uint64(1), w.Stats().SplitRecords)
+		assert.Equal(t, uint64(2), w.Stats().WrittenRecords)
+		assert.Equal(t, []OversizeMode{OversizeSplit}, cb.modes)
+	})
+
+	t.Run("split raises small limits", func(t *testing.T) {
+		conf := isConfigValid(NewConfig().WithMaxRecordSize(8, OversizeSplit))
+		assert.Equal(t, 64, conf.MaxRecordSize())
+		assert.Equal(t, OversizeSplit, conf.OversizeMode())
+	})
+}
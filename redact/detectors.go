@@ -0,0 +1,318 @@
+package redact
+
+import (
+	"bytes"
+	"net"
+)
+
+// bearerPrefix Bearer 令牌的前缀，比较时不区分大小写
+const bearerPrefix = "bearer"
+
+// jwtPrefix JWT 头部 {"alg" 等以 {" 开头的 JSON 经过 base64url 编码后的前缀
+const jwtPrefix = "eyJ"
+
+// appendEmails 以 "@" 为锚点向两侧扩展，收集邮箱地址的区间
+func appendEmails(spans []span, content []byte) []span {
+	for offset := 0; ; {
+		at := bytes.IndexByte(content[offset:], '@')
+		if at < 0 {
+			return spans
+		}
+		at += offset
+		offset = at + 1
+
+		start := at
+		for start > 0 && isLocalChar(content[start-1]) {
+			start--
+		}
+		end, lastDot := at+1, -1
+		for end < len(content) && (isAlnum(content[end]) || content[end] == '-' || content[end] == '.') {
+			if content[end] == '.' {
+				lastDot = end
+			}
+			end++
+		}
+		// 去掉结尾的句点，例如句子末尾的邮箱
+		for end > at+1 && content[end-1] == '.' {
+			end--
+			lastDot = bytes.LastIndexByte(content[at+1:end], '.')
+			if lastDot >= 0 {
+				lastDot += at + 1
+			}
+		}
+
+		if start == at || lastDot <= at+1 || end-lastDot-1 < 2 || !isAlpha(content[lastDot+1:end]) {
+			continue
+		}
+		spans = append(spans, span{start, end})
+		offset = end
+	}
+}
+
+// appendBearerTokens 收集 "Bearer" 之后以空白分隔的令牌区间。
+// 只在 Authorization 一类的上下文中匹配，即 "Bearer" 之前（忽略空白）是冒号、等号或引号，
+// 以免误伤 "the bearer of this message" 这样的普通文本。
+func appendBearerTokens(spans []span, content []byte) []span {
+	for i := 0; i+len(bearerPrefix) < len(content); i++ {
+		if content[i]|0x20 != 'b' || !bytes.EqualFold(content[i:i+len(bearerPrefix)], []byte(bearerPrefix)) {
+			continue
+		}
+		if !isBearerContext(content[:i]) {
+			continue
+		}
+
+		start := i + len(bearerPrefix)
+		for start < len(content) && (content[start] == ' ' || content[start] == '\t') {
+			start++
+		}
+		if start == i+len(bearerPrefix) {
+			continue
+		}
+
+		end := start
+		for end < len(content) && isTokenChar(content[end]) {
+			end++
+		}
+		for end < len(content) && content[end] == '=' {
+			end++
+		}
+		if end > start {
+			spans = append(spans, span{start, end})
+			i = end - 1
+		}
+	}
+	return spans
+}
+
+// isBearerContext 判断 "Bearer" 之前的内容（忽略空白）是否以冒号、等号或引号结尾
+func isBearerContext(before []byte) bool {
+	before = bytes.TrimRight(before, " \t")
+	if len(before) == 0 {
+		return false
+	}
+	switch before[len(before)-1] {
+	case ':', '=', '"', '\'':
+		return true
+	}
+	return false
+}
+
+// appendJWTs 收集以 "eyJ" 开头、由两个句点分隔为三段的 JWT 区间
+func appendJWTs(spans []span, content []byte) []span {
+	for offset := 0; ; {
+		i := bytes.Index(content[offset:], []byte(jwtPrefix))
+		if i < 0 {
+			return spans
+		}
+		start := offset + i
+		offset = start + len(jwtPrefix)
+		if start > 0 && isBase64URLChar(content[start-1]) {
+			continue
+		}
+
+		end, dots := start, 0
+		for end < len(content) {
+			c := content[end]
+			if c == '.' {
+				// 前两段不能为空
+				if dots == 2 || !isBase64URLChar(content[end-1]) {
+					break
+				}
+				dots++
+			} else if !isBase64URLChar(c) {
+				break
+			}
+			end++
+		}
+
+		if dots == 2 {
+			spans = append(spans, span{start, end})
+			offset = end
+		}
+	}
+}
+
+// 卡号的位数范围
+const (
+	minCardDigits = 13
+	maxCardDigits = 19
+)
+
+// appendCards 收集卡号区间：以卡组织的发卡行前缀开头、位数符合该卡组织的规定，并且通过 Luhn 校验。
+// 卡号的数字之间允许单个空格或连字符，前后不能紧邻字母或数字。
+// 只检查 Luhn 时大约十分之一的任意数字都会通过，时间戳和数字 ID 会被误伤，因此同时要求前缀和位数匹配。
+func appendCards(spans []span, content []byte) []span {
+	n := len(content)
+	for i := 0; i < n; i++ {
+		if !isDigit(content[i]) || (i > 0 && isAlnum(content[i-1])) {
+			continue
+		}
+
+		digits, end := 0, i
+		for j := i; j < n; {
+			if isDigit(content[j]) {
+				digits++
+				j++
+				end = j
+				continue
+			}
+			if (content[j] == ' ' || content[j] == '-') && j+1 < n && isDigit(content[j+1]) {
+				j++
+				continue
+			}
+			break
+		}
+
+		if digits >= minCardDigits && digits <= maxCardDigits &&
+			(end == n || !isAlnum(content[end])) && validCardPrefix(content[i:end], digits) && luhn(content[i:end]) {
+			spans = append(spans, span{i, end})
+		}
+		i = end
+	}
+	return spans
+}
+
+// cardRange 一个卡组织的发卡行前缀范围和允许的位数
+type cardRange struct {
+	width     int // 前缀的位数
+	low, high int // 前缀的范围，包含两端
+	minDigits int
+	maxDigits int
+}
+
+// cardRanges 主要卡组织的发卡行前缀（IIN）和卡号位数
+var cardRanges = []cardRange{
+	{1, 4, 4, 13, 19},       // Visa（13、16、19 位）
+	{2, 51, 55, 16, 16},     // Mastercard
+	{4, 2221, 2720, 16, 16}, // Mastercard
+	{2, 34, 34, 15, 15},     // American Express
+	{2, 37, 37, 15, 15},     // American Express
+	{4, 6011, 6011, 16, 19}, // Discover
+	{3, 644, 649, 16, 19},   // Discover
+	{2, 65, 65, 16, 19},     // Discover
+	{4, 3528, 3589, 16, 19}, // JCB
+	{3, 300, 305, 14, 19},   // Diners Club
+	{2, 36, 36, 14, 19},     // Diners Club
+	{2, 38, 39, 16, 19},     // Diners Club
+	{2, 62, 62, 16, 19},     // UnionPay
+	{2, 50, 50, 13, 19},     // Maestro
+	{2, 56, 58, 13, 19},     // Maestro
+}
+
+// validCardPrefix 判断忽略分隔符后的数字是否以某个卡组织的前缀开头，并且位数符合该卡组织的规定
+func validCardPrefix(number []byte, digits int) bool {
+	for _, r := range cardRanges {
+		if digits < r.minDigits || digits > r.maxDigits {
+			continue
+		}
+		if prefix := leadingDigits(number, r.width); prefix >= r.low && prefix <= r.high {
+			return true
+		}
+	}
+	return false
+}
+
+// leadingDigits 返回忽略分隔符后的前 width 位数字组成的整数
+func leadingDigits(number []byte, width int) int {
+	v := 0
+	for _, c := range number {
+		if width == 0 {
+			break
+		}
+		if isDigit(c) {
+			v = v*10 + int(c-'0')
+			width--
+		}
+	}
+	return v
+}
+
+// luhn 对忽略分隔符后的数字做 Luhn 校验
+func luhn(number []byte) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if !isDigit(c) {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validIPv4 判断匹配的内容是否是每段不超过 255 的 IPv4 地址
+func validIPv4(candidate []byte) bool {
+	for _, part := range bytes.Split(candidate, []byte(".")) {
+		v := 0
+		for _, c := range part {
+			v = v*10 + int(c-'0')
+		}
+		if v > 255 {
+			return false
+		}
+	}
+	return true
+}
+
+// validIPv6 判断 content[start:end] 是否是独立的 IPv6 地址。
+// 前后紧邻字母、数字或冒号的匹配不算在内，以排除 "std::io" 之类的误匹配。
+func validIPv6(content []byte, start, end int) bool {
+	if start > 0 && (isAlnum(content[start-1]) || content[start-1] == ':') {
+		return false
+	}
+	if end < len(content) && (isAlnum(content[end]) || content[end] == ':') {
+		return false
+	}
+
+	candidate := content[start:end]
+	if i := bytes.IndexByte(candidate, '%'); i >= 0 {
+		candidate = candidate[:i]
+	}
+	if len(bytes.Trim(candidate, ":")) == 0 {
+		return false
+	}
+	return net.ParseIP(string(candidate)) != nil
+}
+
+// isLocalChar 判断是否是邮箱本地部分允许的字符
+func isLocalChar(c byte) bool {
+	return isAlnum(c) || c == '.' || c == '_' || c == '%' || c == '+' || c == '-'
+}
+
+// isTokenChar 判断是否是 RFC 6750 中 b64token 允许的字符（不含结尾的 "="）
+func isTokenChar(c byte) bool {
+	return isAlnum(c) || c == '-' || c == '.' || c == '_' || c == '~' || c == '+' || c == '/'
+}
+
+// isBase64URLChar 判断是否是 base64url 字符
+func isBase64URLChar(c byte) bool {
+	return isAlnum(c) || c == '-' || c == '_'
+}
+
+// isAlpha 判断是否全部是 ASCII 字母
+func isAlpha(s []byte) bool {
+	for _, c := range s {
+		if (c|0x20) < 'a' || (c|0x20) > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit 判断是否是十进制数字
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isAlnum 判断是否是 ASCII 字母或数字
+func isAlnum(c byte) bool {
+	return isDigit(c) || (c|0x20) >= 'a' && (c|0x20) <= 'z'
+}
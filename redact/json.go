@@ -0,0 +1,248 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// isJSONObject 判断记录是否以 JSON 对象开头
+func isJSONObject(in []byte) bool {
+	trimmed := bytes.TrimLeft(in, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// escapeJSON 返回 s 按 JSON 字符串规则转义后的内容，不含引号
+func escapeJSON(s string) []byte {
+	var buff bytes.Buffer
+	enc := json.NewEncoder(&buff)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// 去掉引号和 Encode 追加的换行
+	return buff.Bytes()[1 : buff.Len()-2]
+}
+
+// stringTracker 从前往后扫描 JSON 文本，判断位置是否位于字符串内
+type stringTracker struct {
+	data []byte
+	pos  int
+	in   bool
+}
+
+// inString 扫描到 pos 并返回 pos 是否位于字符串内，pos 不能小于上一次的参数
+func (t *stringTracker) inString(pos int) bool {
+	for ; t.pos < pos && t.pos < len(t.data); t.pos++ {
+		switch t.data[t.pos] {
+		case '\\':
+			if t.in {
+				t.pos++
+			}
+		case '"':
+			t.in = !t.in
+		}
+	}
+	return t.in
+}
+
+// isLiteralToken 判断字符串之外的区间是否恰好覆盖一个完整的数字或字面量
+func isLiteralToken(data []byte, s span) bool {
+	before := bytes.TrimRight(data[:s.start], " \t\r\n")
+	after := bytes.TrimLeft(data[s.end:], " \t\r\n")
+	if len(before) == 0 || len(after) == 0 {
+		return false
+	}
+	return bytes.IndexByte([]byte(":,["), before[len(before)-1]) >= 0 &&
+		bytes.IndexByte([]byte(",}]"), after[0]) >= 0
+}
+
+// jsonScanner 在不解码整个文档的情况下定位匹配键路径的值
+type jsonScanner struct {
+	data  []byte
+	keys  [][]string
+	path  [][]byte
+	spans []span
+}
+
+// findJSON 收集记录中匹配键路径的值的区间，记录不是合法的 JSON 对象时不收集任何区间
+func (r *Redactor) findJSON(in []byte, spans []span) []span {
+	s := jsonScanner{data: in, keys: r.jsonKeys, spans: spans}
+	start := len(in) - len(bytes.TrimLeft(in, " \t\r\n"))
+	if _, ok := s.value(start); !ok {
+		return spans[:0]
+	}
+	return s.spans
+}
+
+// value 扫描从 pos 开始的值，返回值之后的位置
+func (s *jsonScanner) value(pos int) (int, bool) {
+	pos = s.skipSpace(pos)
+	if pos >= len(s.data) {
+		return pos, false
+	}
+
+	switch s.data[pos] {
+	case '{':
+		return s.object(pos)
+	case '[':
+		return s.array(pos)
+	case '"':
+		return s.str(pos)
+	default:
+		return s.literal(pos)
+	}
+}
+
+// object 扫描一个对象
+func (s *jsonScanner) object(pos int) (int, bool) {
+	pos = s.skipSpace(pos + 1)
+	if pos < len(s.data) && s.data[pos] == '}' {
+		return pos + 1, true
+	}
+
+	for {
+		keyStart := s.skipSpace(pos)
+		keyEnd, ok := s.str(keyStart)
+		if !ok {
+			return keyEnd, false
+		}
+
+		pos = s.skipSpace(keyEnd)
+		if pos >= len(s.data) || s.data[pos] != ':' {
+			return pos, false
+		}
+
+		s.path = append(s.path, decodeKey(s.data[keyStart:keyEnd]))
+		valueStart := s.skipSpace(pos + 1)
+		if s.matches() {
+			// 匹配的值整体替换，内部不再扫描
+			keys := s.keys
+			s.keys = nil
+			pos, ok = s.value(valueStart)
+			s.keys = keys
+			if ok {
+				s.spans = append(s.spans, span{valueStart, pos})
+			}
+		} else {
+			pos, ok = s.value(valueStart)
+		}
+		s.path = s.path[:len(s.path)-1]
+		if !ok {
+			return pos, false
+		}
+
+		pos = s.skipSpace(pos)
+		if pos >= len(s.data) {
+			return pos, false
+		}
+		switch s.data[pos] {
+		case ',':
+			pos++
+		case '}':
+			return pos + 1, true
+		default:
+			return pos, false
+		}
+	}
+}
+
+// array 扫描一个数组，数组元素沿用数组所在的键路径
+func (s *jsonScanner) array(pos int) (int, bool) {
+	pos = s.skipSpace(pos + 1)
+	if pos < len(s.data) && s.data[pos] == ']' {
+		return pos + 1, true
+	}
+
+	for {
+		var ok bool
+		if pos, ok = s.value(pos); !ok {
+			return pos, false
+		}
+
+		pos = s.skipSpace(pos)
+		if pos >= len(s.data) {
+			return pos, false
+		}
+		switch s.data[pos] {
+		case ',':
+			pos++
+		case ']':
+			return pos + 1, true
+		default:
+			return pos, false
+		}
+	}
+}
+
+// str 扫描一个字符串，返回结尾引号之后的位置
+func (s *jsonScanner) str(pos int) (int, bool) {
+	if pos >= len(s.data) || s.data[pos] != '"' {
+		return pos, false
+	}
+	for i := pos + 1; i < len(s.data); i++ {
+		switch s.data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, true
+		}
+	}
+	return len(s.data), false
+}
+
+// literal 扫描数字、true、false 或 null
+func (s *jsonScanner) literal(pos int) (int, bool) {
+	start := pos
+	for pos < len(s.data) {
+		switch s.data[pos] {
+		case ',', '}', ']', ' ', '\t', '\r', '\n':
+			return pos, pos > start
+		}
+		pos++
+	}
+	return pos, pos > start
+}
+
+// skipSpace 跳过空白字符
+func (s *jsonScanner) skipSpace(pos int) int {
+	for pos < len(s.data) {
+		switch s.data[pos] {
+		case ' ', '\t', '\r', '\n':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// matches 判断当前路径是否匹配任一键路径
+func (s *jsonScanner) matches() bool {
+	for _, key := range s.keys {
+		if len(key) != len(s.path) {
+			continue
+		}
+		matched := true
+		for i, segment := range key {
+			if segment != "*" && segment != string(s.path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeKey 解码带引号的键，只有包含转义字符时才分配内存
+func decodeKey(quoted []byte) []byte {
+	raw := quoted[1 : len(quoted)-1]
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return raw
+	}
+	var key string
+	if err := json.Unmarshal(quoted, &key); err != nil {
+		return raw
+	}
+	return []byte(key)
+}
@@ -0,0 +1,295 @@
+// Package redact 提供在日志写入磁盘前脱敏的转换阶段。
+//
+// Redactor 通过内置检测器（邮箱、Bearer 令牌、JWT、通过 Luhn 校验的银行卡号、可选的 IPv4/IPv6）
+// 和自定义正则表达式查找敏感内容，并替换为掩码；对于 JSON 对象记录，还可以按键路径脱敏整个值。
+// Redactor.Transform 可以直接注册到 law.Config.WithTransforms，在轮询器协程上执行。
+//
+//	r := redact.New().WithJSONKeys("password", "user.token")
+//	conf := law.NewConfig().WithTransforms(r.Transform)
+package redact
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultMask 默认的掩码
+const DefaultMask = "[REDACTED]"
+
+// Detector 内置检测器
+type Detector uint8
+
+const (
+	// Email 邮箱地址
+	Email Detector = 1 << iota
+
+	// BearerToken "Authorization: Bearer" 等上下文中 "Bearer" 之后的令牌，保留 "Bearer " 前缀
+	BearerToken
+
+	// JWT 三段式的 JSON Web Token
+	JWT
+
+	// CreditCard 以卡组织的发卡行前缀开头、位数符合规定、允许以空格或连字符分隔并通过 Luhn 校验的卡号
+	CreditCard
+
+	// IPv4 IPv4 地址
+	IPv4
+
+	// IPv6 IPv6 地址
+	IPv6
+)
+
+// DefaultDetectors 默认启用的检测器，IP 地址默认不脱敏
+const DefaultDetectors = Email | BearerToken | JWT | CreditCard
+
+// 内置检测器使用的正则表达式
+var (
+	ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern = regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}(?:%[0-9a-z]+)?`)
+)
+
+// span 待替换的区间 [start, end)
+type span struct {
+	start, end int
+}
+
+// state 单次脱敏使用的临时状态
+type state struct {
+	spans []span
+	buff  bytes.Buffer
+}
+
+// statePool 复用临时状态
+var statePool = sync.Pool{
+	New: func() any {
+		return &state{spans: make([]span, 0, 8)}
+	},
+}
+
+// pattern 自定义正则表达式
+type pattern struct {
+	re    *regexp.Regexp
+	group bool
+}
+
+// Redactor 脱敏器，配置完成后可以被多个写入器并发使用
+type Redactor struct {
+	detectors Detector
+	patterns  []pattern
+	jsonKeys  [][]string
+	mask      []byte
+	jsonMask  []byte // 经过 JSON 转义的掩码，不含引号
+}
+
+// New 创建使用 DefaultDetectors 和 DefaultMask 的脱敏器
+func New() *Redactor {
+	return &Redactor{
+		detectors: DefaultDetectors,
+		mask:      []byte(DefaultMask),
+		jsonMask:  escapeJSON(DefaultMask),
+	}
+}
+
+// WithDetectors 设置启用的内置检测器，为 0 时不使用内置检测器
+func (r *Redactor) WithDetectors(detectors Detector) *Redactor {
+	r.detectors = detectors
+	return r
+}
+
+// WithPatterns 追加自定义正则表达式。表达式包含分组时只替换第一个分组，否则替换整个匹配
+func (r *Redactor) WithPatterns(patterns ...*regexp.Regexp) *Redactor {
+	for _, re := range patterns {
+		if re != nil {
+			r.patterns = append(r.patterns, pattern{re: re, group: re.NumSubexp() > 0})
+		}
+	}
+	return r
+}
+
+// WithJSONKeys 追加 JSON 键路径，以 "." 分隔，"*" 匹配任意键，例如 "user.password" 或 "*.token"。
+// 记录是 JSON 对象时，匹配路径的值（包括对象和数组）会被整体替换为掩码字符串；数组不占用路径层级。
+func (r *Redactor) WithJSONKeys(paths ...string) *Redactor {
+	for _, p := range paths {
+		if p != "" {
+			r.jsonKeys = append(r.jsonKeys, strings.Split(p, "."))
+		}
+	}
+	return r
+}
+
+// WithMask 设置掩码，写入 JSON 字符串时会按 JSON 的规则转义
+func (r *Redactor) WithMask(mask string) *Redactor {
+	r.mask = []byte(mask)
+	r.jsonMask = escapeJSON(mask)
+	return r
+}
+
+// Transform 实现 law.Transform：没有敏感内容时保持记录不变，否则把脱敏后的记录写入 out
+func (r *Redactor) Transform(in []byte, out *bytes.Buffer) (bool, error) {
+	st := statePool.Get().(*state)
+	defer func() {
+		st.spans = st.spans[:0]
+		st.buff.Reset()
+		statePool.Put(st)
+	}()
+
+	content, rewritten, isJSON := in, false, isJSONObject(in)
+	if len(r.jsonKeys) > 0 && isJSON {
+		if st.spans = r.findJSON(in, st.spans[:0]); len(st.spans) > 0 {
+			r.replaceValues(&st.buff, in, st.spans)
+			content, rewritten = st.buff.Bytes(), true
+		}
+	}
+
+	st.spans = r.find(content, st.spans[:0])
+	if len(st.spans) == 0 {
+		if rewritten {
+			out.Write(content)
+		}
+		return true, nil
+	}
+
+	if isJSON {
+		r.replaceInJSON(out, content, mergeSpans(st.spans))
+	} else {
+		r.replace(out, content, mergeSpans(st.spans))
+	}
+	return true, nil
+}
+
+// replace 将 content 中的区间替换为掩码后写入 out
+func (r *Redactor) replace(out *bytes.Buffer, content []byte, spans []span) {
+	last := 0
+	for _, s := range spans {
+		out.Write(content[last:s.start])
+		out.Write(r.mask)
+		last = s.end
+	}
+	out.Write(content[last:])
+}
+
+// replaceValues 将 content 中的 JSON 值区间替换为掩码字符串后写入 out
+func (r *Redactor) replaceValues(out *bytes.Buffer, content []byte, spans []span) {
+	last := 0
+	for _, s := range spans {
+		out.Write(content[last:s.start])
+		out.WriteByte('"')
+		out.Write(r.jsonMask)
+		out.WriteByte('"')
+		last = s.end
+	}
+	out.Write(content[last:])
+}
+
+// replaceInJSON 将 JSON 记录中检测到的区间替换为掩码后写入 out，保持记录是合法的 JSON：
+// 字符串内的掩码经过转义，恰好覆盖字符串之外的数字或字面量的区间以掩码字符串替换。
+func (r *Redactor) replaceInJSON(out *bytes.Buffer, content []byte, spans []span) {
+	tracker := stringTracker{data: content}
+	last := 0
+	for _, s := range spans {
+		out.Write(content[last:s.start])
+		switch {
+		case tracker.inString(s.start):
+			out.Write(r.jsonMask)
+		case isLiteralToken(content, s):
+			out.WriteByte('"')
+			out.Write(r.jsonMask)
+			out.WriteByte('"')
+		default:
+			out.Write(r.mask)
+		}
+		tracker.inString(s.end)
+		last = s.end
+	}
+	out.Write(content[last:])
+}
+
+// Redact 返回脱敏后的副本，便于在 Transform 之外使用
+func (r *Redactor) Redact(in []byte) []byte {
+	var out bytes.Buffer
+	_, _ = r.Transform(in, &out)
+	if out.Len() == 0 {
+		return append([]byte(nil), in...)
+	}
+	return out.Bytes()
+}
+
+// find 收集所有需要替换的区间
+func (r *Redactor) find(content []byte, spans []span) []span {
+	if len(content) == 0 {
+		return spans
+	}
+
+	if r.detectors&Email != 0 {
+		spans = appendEmails(spans, content)
+	}
+	if r.detectors&BearerToken != 0 {
+		spans = appendBearerTokens(spans, content)
+	}
+	if r.detectors&JWT != 0 {
+		spans = appendJWTs(spans, content)
+	}
+	if r.detectors&CreditCard != 0 {
+		spans = appendCards(spans, content)
+	}
+	if r.detectors&IPv4 != 0 && bytes.IndexByte(content, '.') >= 0 {
+		for _, m := range ipv4Pattern.FindAllIndex(content, -1) {
+			if validIPv4(content[m[0]:m[1]]) {
+				spans = append(spans, span{m[0], m[1]})
+			}
+		}
+	}
+	if r.detectors&IPv6 != 0 && bytes.Count(content, []byte(":")) >= 2 {
+		for _, m := range ipv6Pattern.FindAllIndex(content, -1) {
+			if validIPv6(content, m[0], m[1]) {
+				spans = append(spans, span{m[0], m[1]})
+			}
+		}
+	}
+	for _, p := range r.patterns {
+		spans = appendMatches(spans, p.re, content, p.group)
+	}
+	return spans
+}
+
+// appendMatches 收集正则表达式的匹配区间，group 为 true 时只收集第一个分组
+func appendMatches(spans []span, re *regexp.Regexp, content []byte, group bool) []span {
+	if !group {
+		for _, m := range re.FindAllIndex(content, -1) {
+			if m[1] > m[0] {
+				spans = append(spans, span{m[0], m[1]})
+			}
+		}
+		return spans
+	}
+	for _, m := range re.FindAllSubmatchIndex(content, -1) {
+		if len(m) >= 4 && m[2] >= 0 && m[3] > m[2] {
+			spans = append(spans, span{m[2], m[3]})
+		}
+	}
+	return spans
+}
+
+// mergeSpans 排序并合并重叠的区间
+func mergeSpans(spans []span) []span {
+	// 区间通常很少，插入排序避免 sort.Slice 的内存分配
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j].start < spans[j-1].start; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
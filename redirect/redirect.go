@@ -0,0 +1,143 @@
+// Package redirect 将标准库 log 包的输出以及进程的标准输出、标准错误重定向到 io.Writer（通常是 law.WriteAsyncer），
+// 使第三方库直接打印到 stderr 的内容也经过同一条异步写入管道。
+//
+// 标准输出和标准错误通过 os.Pipe 和 dup 替换文件描述符 1 和 2 实现，目前只支持 Linux。
+// 读取协程按行切分管道中的内容，每行调用一次 Write。
+//
+// 注意：w 不能写回被重定向的流本身，否则会形成循环。需要继续输出到原来的终端时，
+// 可以使用 Redirection.Original 返回的文件作为写入器的底层输出。
+package redirect
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	law "github.com/shengyanli1982/law"
+)
+
+// DefaultMaxLineSize 默认的单行最大长度
+const DefaultMaxLineSize = 64 * 1024
+
+// 错误定义
+var (
+	ErrorWriterIsNil         = law.ErrorWriterIsNil
+	ErrorUnsupportedPlatform = errors.New("file descriptor redirection is not supported on this platform")
+)
+
+// Options 重定向选项
+type Options struct {
+	// Stdout 是否重定向标准输出（文件描述符 1）
+	Stdout bool
+
+	// Stderr 是否重定向标准错误（文件描述符 2）
+	Stderr bool
+
+	// MaxLineSize 单行最大长度，超过时按该长度切分，<= 0 时使用 DefaultMaxLineSize
+	MaxLineSize int
+}
+
+// Redirection 一次重定向的句柄，用于恢复原来的输出
+type Redirection struct {
+	prevLog io.Writer
+	streams []*stream
+	once    sync.Once
+	err     error
+}
+
+// Redirect 将标准库 log 包的输出重定向到 w，opts 为 nil 时只重定向 log 包。
+// 任何一步失败时会撤销已经完成的重定向并返回错误。
+func Redirect(w io.Writer, opts *Options) (*Redirection, error) {
+	if w == nil {
+		return nil, ErrorWriterIsNil
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	maxLineSize := opts.MaxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
+	r := &Redirection{}
+
+	if opts.Stdout {
+		s, err := redirectFile(os.Stdout, w, maxLineSize)
+		if err != nil {
+			return nil, err
+		}
+		r.streams = append(r.streams, s)
+	}
+
+	if opts.Stderr {
+		s, err := redirectFile(os.Stderr, w, maxLineSize)
+		if err != nil {
+			_ = r.Restore()
+			return nil, err
+		}
+		r.streams = append(r.streams, s)
+	}
+
+	r.prevLog = log.Writer()
+	log.SetOutput(w)
+
+	return r, nil
+}
+
+// Original 返回被重定向之前的标准输出或标准错误的副本，f 未被重定向时返回 nil。
+// 返回的文件在 Restore 后关闭。
+func (r *Redirection) Original(f *os.File) *os.File {
+	for _, s := range r.streams {
+		if s.target == f {
+			return s.saved
+		}
+	}
+	return nil
+}
+
+// Restore 恢复原来的输出，并等待读取协程写完管道中剩余的内容。可以重复调用。
+func (r *Redirection) Restore() error {
+	r.once.Do(func() {
+		if r.prevLog != nil {
+			log.SetOutput(r.prevLog)
+		}
+
+		var errs []error
+		for i := len(r.streams) - 1; i >= 0; i-- {
+			if err := r.streams[i].restore(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		r.err = errors.Join(errs...)
+	})
+	return r.err
+}
+
+// stream 一个被重定向的文件描述符
+type stream struct {
+	target *os.File
+	saved  *os.File
+	reader *os.File
+	writer *os.File
+	done   chan struct{}
+}
+
+// copyLines 按行读取 reader 并逐行写入 w，单行超过 maxLineSize 时按该长度切分
+func copyLines(reader io.Reader, w io.Writer, maxLineSize int, done chan struct{}) {
+	defer close(done)
+
+	br := bufio.NewReaderSize(reader, maxLineSize)
+	for {
+		line, err := br.ReadSlice('\n')
+		if len(line) > 0 {
+			_, _ = w.Write(line)
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return
+		}
+	}
+}
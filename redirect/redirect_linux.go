@@ -0,0 +1,69 @@
+//go:build linux
+
+package redirect
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// redirectFile 用管道替换 target 的文件描述符，并启动读取协程
+func redirectFile(target *os.File, w io.Writer, maxLineSize int) (*stream, error) {
+	fd := int(target.Fd())
+
+	savedFd, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, err
+	}
+	syscall.CloseOnExec(savedFd)
+	saved := os.NewFile(uintptr(savedFd), target.Name())
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		_ = saved.Close()
+		return nil, err
+	}
+
+	if err := syscall.Dup3(int(writer.Fd()), fd, 0); err != nil {
+		_ = saved.Close()
+		_ = reader.Close()
+		_ = writer.Close()
+		return nil, err
+	}
+
+	s := &stream{
+		target: target,
+		saved:  saved,
+		reader: reader,
+		writer: writer,
+		done:   make(chan struct{}),
+	}
+	go copyLines(reader, w, maxLineSize, s.done)
+
+	return s, nil
+}
+
+// restore 恢复原来的文件描述符，关闭管道写端后等待读取协程结束
+func (s *stream) restore() error {
+	// 文件描述符仍指向管道时读取协程收不到 EOF，不能等待它结束
+	if err := syscall.Dup3(int(s.saved.Fd()), int(s.target.Fd()), 0); err != nil {
+		return errors.Join(err, s.writer.Close())
+	}
+
+	var errs []error
+	if err := s.writer.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	<-s.done
+
+	if err := s.reader.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.saved.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
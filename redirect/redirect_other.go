@@ -0,0 +1,18 @@
+//go:build !linux
+
+package redirect
+
+import (
+	"io"
+	"os"
+)
+
+// redirectFile 当前平台不支持重定向文件描述符
+func redirectFile(*os.File, io.Writer, int) (*stream, error) {
+	return nil, ErrorUnsupportedPlatform
+}
+
+// restore 当前平台不会创建 stream
+func (s *stream) restore() error {
+	return nil
+}
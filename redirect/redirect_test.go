@@ -0,0 +1,81 @@
+package redirect
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+
+	law "github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+)
+
+// memorySink 是一个并发安全的内存输出
+type memorySink struct {
+	mu   sync.Mutex
+	buff bytes.Buffer
+}
+
+func (s *memorySink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.Write(p)
+}
+
+func (s *memorySink) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buff.String()
+}
+
+func TestRedirect_StdLog(t *testing.T) {
+	sink := &memorySink{}
+	w := law.NewWriteAsyncer(sink, nil)
+
+	prevFlags := log.Flags()
+	log.SetFlags(0)
+	defer log.SetFlags(prevFlags)
+
+	prevOutput := log.Writer()
+
+	r, err := Redirect(w, nil)
+	assert.Nil(t, err)
+
+	log.Print("hello")
+	assert.Nil(t, r.Restore())
+	assert.Nil(t, r.Restore())
+	assert.Equal(t, prevOutput, log.Writer())
+	w.Stop()
+
+	assert.Equal(t, "hello\n", sink.String())
+}
+
+func TestRedirect_Stderr(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("file descriptor redirection is only supported on linux")
+	}
+
+	sink := &memorySink{}
+	w := law.NewWriteAsyncer(sink, nil)
+
+	r, err := Redirect(w, &Options{Stderr: true, MaxLineSize: 16})
+	assert.Nil(t, err)
+	assert.NotNil(t, r.Original(os.Stderr))
+	assert.Nil(t, r.Original(os.Stdout))
+
+	fmt.Fprintln(os.Stderr, "line one")
+	fmt.Fprint(os.Stderr, "a line longer than sixteen bytes\nno newline")
+	assert.Nil(t, r.Restore())
+	w.Stop()
+
+	assert.Equal(t, "line one\na line longer than sixteen bytes\nno newline", sink.String())
+	assert.Equal(t, uint64(5), w.Stats().WrittenRecords)
+}
+
+func TestRedirect_NilWriter(t *testing.T) {
+	_, err := Redirect(nil, nil)
+	assert.ErrorIs(t, err, ErrorWriterIsNil)
+}
@@ -0,0 +1,182 @@
+package law
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	ir "github.com/shengyanli1982/law/internal/registry"
+)
+
+// ErrorNameInUse 名称已被另一个写入器使用，与 metrics 和 debug 包注册时返回的错误相同
+var ErrorNameInUse = ir.ErrorNameInUse
+
+// registry 进程级的写入器注册表
+type registry struct {
+	mu      sync.Mutex
+	writers map[*WriteAsyncer]string
+	names   map[string]*WriteAsyncer
+}
+
+// writerRegistry 默认的注册表
+var writerRegistry = &registry{
+	writers: make(map[*WriteAsyncer]string),
+	names:   make(map[string]*WriteAsyncer),
+}
+
+// add 添加写入器，name 为空或已被其他写入器使用时只加入集合，不占用名称
+func (r *registry) add(name string, wa *WriteAsyncer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if prev, ok := r.names[name]; ok && prev != wa {
+		if _, exists := r.writers[wa]; !exists {
+			r.writers[wa] = ""
+		}
+		return ErrorNameInUse
+	}
+	if old := r.writers[wa]; old != "" && old != name {
+		delete(r.names, old)
+	}
+	r.writers[wa] = name
+	if name != "" {
+		r.names[name] = wa
+	}
+	return nil
+}
+
+// remove 移除写入器
+func (r *registry) remove(wa *WriteAsyncer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name, ok := r.writers[wa]; ok {
+		if name != "" && r.names[name] == wa {
+			delete(r.names, name)
+		}
+		delete(r.writers, wa)
+	}
+}
+
+// snapshot 返回所有已注册的写入器
+func (r *registry) snapshot() []*WriteAsyncer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	writers := make([]*WriteAsyncer, 0, len(r.writers))
+	for wa := range r.writers {
+		writers = append(writers, wa)
+	}
+	return writers
+}
+
+// Register 以 name 将写入器加入进程级注册表，Stop 时自动移除。
+// 使用 Config.WithName 创建的写入器会自动注册。name 已被另一个运行中的写入器使用时返回 ErrorNameInUse，
+// 此时写入器不会占用该名称，但仍然参与 FlushAll 和 StopAll。
+func Register(name string, wa *WriteAsyncer) error {
+	if wa == nil {
+		return ErrorWriterIsNil
+	}
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+	return writerRegistry.add(name, wa)
+}
+
+// Unregister 从注册表中移除 name 对应的写入器，不会停止写入器
+func Unregister(name string) {
+	if wa := Lookup(name); wa != nil {
+		writerRegistry.remove(wa)
+	}
+}
+
+// Lookup 返回 name 对应的写入器，不存在时返回 nil
+func Lookup(name string) *WriteAsyncer {
+	writerRegistry.mu.Lock()
+	defer writerRegistry.mu.Unlock()
+	return writerRegistry.names[name]
+}
+
+// RegisteredNames 按字典序返回所有已注册的名称
+func RegisteredNames() []string {
+	writerRegistry.mu.Lock()
+	names := make([]string, 0, len(writerRegistry.names))
+	for name := range writerRegistry.names {
+		names = append(names, name)
+	}
+	writerRegistry.mu.Unlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Registered 返回所有已注册的写入器，包括没有名称的写入器
+func Registered() []*WriteAsyncer {
+	return writerRegistry.snapshot()
+}
+
+// FlushAll 并发刷新所有已注册的写入器，返回所有刷新错误；ctx 结束时不再等待并返回 ctx.Err()
+func FlushAll(ctx context.Context) error {
+	writers := writerRegistry.snapshot()
+	errs := make([]error, len(writers))
+	return waitAll(ctx, writers, func(i int, wa *WriteAsyncer) {
+		err := wa.flush(ctx)
+		if !errors.Is(err, ErrorWriteAsyncerIsClosed) {
+			errs[i] = err
+		}
+	}, errs)
+}
+
+// StopAll 并发停止所有已注册的写入器，写出队列中剩余的日志；ctx 结束时不再等待并返回 ctx.Err()
+func StopAll(ctx context.Context) error {
+	return waitAll(ctx, writerRegistry.snapshot(), func(_ int, wa *WriteAsyncer) {
+		wa.Stop()
+	}, nil)
+}
+
+// DrainOnExit 停止所有已注册的写入器，最多等待 timeout，用于在 main 中以 defer 调用：
+//
+//	func main() {
+//		defer law.DrainOnExit(5 * time.Second)
+//		...
+//	}
+//
+// 发生 panic 时先写出剩余的日志，再以原来的值继续 panic。必须直接被 defer 调用才能捕获 panic。
+func DrainOnExit(timeout time.Duration) {
+	r := recover()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	_ = StopAll(ctx)
+	cancel()
+
+	if r != nil {
+		panic(r)
+	}
+}
+
+// waitAll 为每个写入器并发执行 fn，等待全部完成或 ctx 结束
+func waitAll(ctx context.Context, writers []*WriteAsyncer, fn func(i int, wa *WriteAsyncer), errs []error) error {
+	var wg sync.WaitGroup
+	for i, wa := range writers {
+		wg.Add(1)
+		go func(i int, wa *WriteAsyncer) {
+			defer wg.Done()
+			fn(i, wa)
+		}(i, wa)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return errors.Join(errs...)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
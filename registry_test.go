@@ -0,0 +1,79 @@
+package law
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// isRegistered 按指针判断写入器是否已注册，避免深度比较正在运行的写入器
+func isRegistered(wa *WriteAsyncer) bool {
+	for _, registered := range Registered() {
+		if registered == wa {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	access := NewWriteAsyncer(&bytes.Buffer{}, NewConfig().WithName("access"))
+	assert.Equal(t, access, Lookup("access"))
+	assert.Contains(t, RegisteredNames(), "access")
+
+	// 同名的写入器不占用名称，但仍然参与 StopAll
+	duplicate := NewWriteAsyncer(&bytes.Buffer{}, NewConfig().WithName("access"))
+	assert.Equal(t, access, Lookup("access"))
+	assert.True(t, isRegistered(duplicate))
+	assert.ErrorIs(t, duplicate.RecentErrors()[0].Err, ErrorNameInUse)
+	assert.ErrorIs(t, Register("access", duplicate), ErrorNameInUse)
+
+	Unregister("access")
+	assert.Nil(t, Lookup("access"))
+	assert.Nil(t, Register("access", duplicate))
+	assert.Equal(t, duplicate, Lookup("access"))
+
+	// Stop 自动移除
+	access.Stop()
+	duplicate.Stop()
+	assert.Nil(t, Lookup("access"))
+	assert.False(t, isRegistered(access))
+	assert.ErrorIs(t, Register("access", access), ErrorWriteAsyncerIsClosed)
+}
+
+func TestRegistry_FlushAllStopAll(t *testing.T) {
+	app, audit := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	wApp := NewWriteAsyncer(app, NewConfig().WithName("app"))
+	wAudit := NewWriteAsyncer(audit, NewConfig().WithName("audit"))
+
+	_, _ = wApp.Write([]byte("app\n"))
+	_, _ = wAudit.Write([]byte("audit\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, FlushAll(ctx))
+	assert.Equal(t, uint64(1), wApp.Stats().Flushes)
+	assert.Equal(t, uint64(1), wAudit.Stats().Flushes)
+
+	_, _ = wApp.Write([]byte("tail\n"))
+	assert.Nil(t, StopAll(ctx))
+	assert.Equal(t, "app\ntail\n", app.String())
+	assert.Nil(t, Lookup("app"))
+	assert.Nil(t, Lookup("audit"))
+}
+
+func TestDrainOnExit(t *testing.T) {
+	buff := bytes.NewBuffer(nil)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		w := NewWriteAsyncer(buff, NewConfig().WithName("panic"))
+		defer DrainOnExit(time.Second)
+		_, _ = w.Write([]byte("before panic\n"))
+		panic("boom")
+	})
+	assert.Equal(t, "before panic\n", buff.String())
+	assert.Nil(t, Lookup("panic"))
+}
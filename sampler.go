@@ -0,0 +1,236 @@
+package law
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shengyanli1982/law/internal/utils"
+)
+
+// DefaultSummaryInterval 默认的抑制摘要间隔
+const DefaultSummaryInterval = 10 * time.Second
+
+// samplerCounters 按键采样时的计数器数量，键通过哈希映射到计数器上
+const samplerCounters = 4096
+
+// SummaryFunc 将一段时间内被抑制的记录数写成一条摘要记录
+type SummaryFunc func(suppressed uint64, interval time.Duration, out *bytes.Buffer)
+
+// DefaultSummary 写出 "law: suppressed N records in the last D" 形式的摘要
+func DefaultSummary(suppressed uint64, interval time.Duration, out *bytes.Buffer) {
+	out.WriteString("law: suppressed ")
+	out.WriteString(strconv.FormatUint(suppressed, 10))
+	out.WriteString(" records in the last ")
+	out.WriteString(interval.Round(time.Millisecond).String())
+	out.WriteByte('\n')
+}
+
+// Sampler 是位于 WriteAsyncer 之前的采样器，在调用方协程上决定记录是否入队，被丢弃的记录不会被复制。
+//
+// 可以组合使用两种策略：
+//   - WithFirstThereafter：每个时间窗口内保留前 first 条记录，之后每 thereafter 条保留一条；
+//     设置了 WithKey 时按键分别计数，例如按错误类型限制重复日志。
+//   - WithRateLimit：令牌桶限速，对写入器的所有记录生效。
+//
+// 轮询器按 WithSummaryInterval 的间隔写出一条摘要记录，报告期间被抑制的记录数。
+// 一个 Sampler 只应配置给一个写入器。
+type Sampler struct {
+	window     int64
+	first      uint64
+	thereafter uint64
+	counters   []sampleCounter
+	key        func(record []byte) []byte
+
+	bucket *tokenBucket
+
+	summaryInterval time.Duration
+	summary         SummaryFunc
+	suppressed      atomic.Uint64
+	lastSummary     time.Time
+}
+
+// sampleCounter 一个时间窗口内的计数
+type sampleCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   int64
+}
+
+// NewSampler 创建不限制任何记录的采样器，摘要间隔为 DefaultSummaryInterval
+func NewSampler() *Sampler {
+	return &Sampler{
+		summaryInterval: DefaultSummaryInterval,
+		summary:         DefaultSummary,
+	}
+}
+
+// WithFirstThereafter 设置每个 window 内保留前 first 条记录，之后每 thereafter 条保留一条，
+// thereafter 为 0 时丢弃窗口内其余的记录。window <= 0 时不启用该策略。
+func (s *Sampler) WithFirstThereafter(window time.Duration, first, thereafter int) *Sampler {
+	if window <= 0 {
+		s.window, s.counters = 0, nil
+		return s
+	}
+	if first < 0 {
+		first = 0
+	}
+	if thereafter < 0 {
+		thereafter = 0
+	}
+	s.window, s.first, s.thereafter = int64(window), uint64(first), uint64(thereafter)
+	if s.counters == nil {
+		s.counters = make([]sampleCounter, samplerCounters)
+	}
+	return s
+}
+
+// WithKey 设置从记录中提取键的函数，WithFirstThereafter 按键分别计数。
+// 不同的键可能因哈希冲突共用计数；延迟编码的记录在入队时没有内容，使用同一个空键。
+func (s *Sampler) WithKey(key func(record []byte) []byte) *Sampler {
+	s.key = key
+	return s
+}
+
+// WithRateLimit 设置令牌桶限速，每秒补充 perSecond 个令牌，最多积累 burst 个。perSecond <= 0 时不限速。
+func (s *Sampler) WithRateLimit(perSecond float64, burst int) *Sampler {
+	if perSecond <= 0 {
+		s.bucket = nil
+		return s
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	s.bucket = &tokenBucket{rate: perSecond / float64(time.Second), burst: float64(burst), tokens: float64(burst), last: utils.Nanotime()}
+	return s
+}
+
+// WithSummaryInterval 设置摘要间隔，小于等于 0 时不写出摘要
+func (s *Sampler) WithSummaryInterval(interval time.Duration) *Sampler {
+	s.summaryInterval = interval
+	return s
+}
+
+// WithSummary 设置摘要的格式，为 nil 时使用 DefaultSummary
+func (s *Sampler) WithSummary(fn SummaryFunc) *Sampler {
+	if fn == nil {
+		fn = DefaultSummary
+	}
+	s.summary = fn
+	return s
+}
+
+// Allow 判断记录是否保留，可以被多个协程并发调用
+func (s *Sampler) Allow(record []byte) bool {
+	if s.counters != nil && !s.allowWindow(record) {
+		s.suppressed.Add(1)
+		return false
+	}
+	if s.bucket != nil && !s.bucket.allow(utils.Nanotime()) {
+		s.suppressed.Add(1)
+		return false
+	}
+	return true
+}
+
+// allowWindow 按时间窗口计数判断记录是否保留
+func (s *Sampler) allowWindow(record []byte) bool {
+	index := 0
+	if s.key != nil && record != nil {
+		index = int(hashKey(s.key(record)) % samplerCounters)
+	}
+
+	c := &s.counters[index]
+	now := utils.Nanotime()
+	var n uint64
+	if now < c.resetAt.Load() {
+		n = c.count.Add(1)
+	} else {
+		// 窗口切换时的并发写入可能少计几次，与精确计数相比这是可以接受的
+		c.count.Store(1)
+		c.resetAt.Store(now + s.window)
+		n = 1
+	}
+
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// allow 消耗一个令牌
+func (b *tokenBucket) allow(now int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now - b.last; elapsed > 0 {
+		b.tokens += float64(elapsed) * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// start 以 now 作为第一个摘要间隔的起点，在写入器创建时调用
+func (s *Sampler) start(now time.Time) {
+	s.lastSummary = now
+}
+
+// writeSummary 在摘要间隔到期且有被抑制的记录时写出摘要，只在轮询器协程上调用
+func (s *Sampler) writeSummary(now time.Time, out *bytes.Buffer) {
+	s.flushSummary(now, out, false)
+}
+
+// writeFinalSummary 不等摘要间隔到期，写出最后一次摘要之后被抑制的记录数，在写入器停止时调用
+func (s *Sampler) writeFinalSummary(now time.Time, out *bytes.Buffer) {
+	s.flushSummary(now, out, true)
+}
+
+// flushSummary 写出摘要，force 为 false 时只在摘要间隔到期后写出
+func (s *Sampler) flushSummary(now time.Time, out *bytes.Buffer, force bool) {
+	if s.summaryInterval <= 0 {
+		return
+	}
+	if s.lastSummary.IsZero() {
+		s.lastSummary = now
+		if !force {
+			return
+		}
+	}
+
+	interval := now.Sub(s.lastSummary)
+	if !force && interval < s.summaryInterval {
+		return
+	}
+	s.lastSummary = now
+
+	if suppressed := s.suppressed.Swap(0); suppressed > 0 {
+		s.summary(suppressed, interval, out)
+	}
+}
+
+// hashKey 计算键的 FNV-1a 哈希
+func hashKey(key []byte) uint32 {
+	h := uint32(2166136261)
+	for _, c := range key {
+		h ^= uint32(c)
+		h *= 16777619
+	}
+	return h
+}
@@ -0,0 +1,103 @@
+package law
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler_FirstThereafter(t *testing.T) {
+	s := NewSampler().WithFirstThereafter(time.Hour, 2, 3)
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.Allow([]byte("same")) {
+			kept = append(kept, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, kept)
+	assert.Equal(t, uint64(6), s.suppressed.Load())
+}
+
+func TestSampler_Key(t *testing.T) {
+	s := NewSampler().
+		WithFirstThereafter(time.Hour, 1, 0).
+		WithKey(func(r []byte) []byte {
+			if i := bytes.IndexByte(r, ' '); i >= 0 {
+				return r[:i]
+			}
+			return r
+		})
+
+	assert.True(t, s.Allow([]byte("timeout a")))
+	assert.False(t, s.Allow([]byte("timeout b")))
+	assert.True(t, s.Allow([]byte("refused a")))
+	assert.False(t, s.Allow([]byte("refused b")))
+}
+
+func TestSampler_Window(t *testing.T) {
+	s := NewSampler().WithFirstThereafter(20*time.Millisecond, 1, 0)
+
+	assert.True(t, s.Allow(nil))
+	assert.False(t, s.Allow(nil))
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, s.Allow(nil))
+}
+
+func TestSampler_RateLimit(t *testing.T) {
+	s := NewSampler().WithRateLimit(1, 3)
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if s.Allow(nil) {
+			allowed++
+		}
+	}
+	assert.Equal(t, 3, allowed)
+}
+
+func TestWriteAsyncer_Sampler(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	sampler := NewSampler().WithFirstThereafter(time.Hour, 2, 0).WithSummaryInterval(20 * time.Millisecond)
+	conf := NewConfig().WithSampler(sampler).WithHeartbeatInterval(10 * time.Millisecond).WithIdleTimeout(10 * time.Millisecond)
+	w := NewWriteAsyncer(buff, conf)
+
+	for i := 0; i < 10; i++ {
+		n, err := w.Write([]byte("error: connection refused\n"))
+		assert.Equal(t, 26, n)
+		assert.Nil(t, err)
+	}
+	_, _ = w.WriteOwned(bytes.NewBufferString("owned\n"))
+	assert.Equal(t, uint64(9), w.Stats().SuppressedRecords)
+
+	assert.Eventually(t, func() bool {
+		return w.Stats().WrittenRecords == 3
+	}, time.Second, 10*time.Millisecond)
+	w.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "error: connection refused", lines[0])
+	assert.True(t, strings.HasPrefix(lines[2], "law: suppressed 9 records in the last "), lines[2])
+}
+
+func TestWriteAsyncer_SamplerSummaryOnStop(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	sampler := NewSampler().WithFirstThereafter(time.Hour, 1, 0).WithSummaryInterval(time.Hour)
+	w := NewWriteAsyncer(buff, NewConfig().WithSampler(sampler))
+
+	for i := 0; i < 5; i++ {
+		_, _ = w.Write([]byte("burst\n"))
+	}
+
+	// 摘要间隔还没有到期，Stop 时写出最后一次摘要
+	w.Stop()
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "burst", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "law: suppressed 4 records in the last "), lines[1])
+	assert.Equal(t, uint64(2), w.Stats().WrittenRecords)
+}
@@ -0,0 +1,202 @@
+// Package signals 安装 os/signal 处理器，把常见的运维信号与写入器的操作连接起来：
+//
+//   - SIGHUP：重新打开文件输出（WriteAsyncer.Reopen），配合 logrotate 使用；
+//   - SIGUSR1：立即刷新（WriteAsyncer.Flush）；
+//   - SIGTERM、SIGINT：在限定时间内停止所有写入器，写出队列中剩余的日志，然后恢复信号的默认处理并重新发送该信号，
+//     使进程以信号原本的方式退出。停止期间再次收到退出信号时不再等待。
+//
+// 处理器是可选的，只有调用 Install 后才会生效。Windows 上只处理 SIGTERM 和 SIGINT。
+//
+//	h := signals.Install(nil, accessLog, appLog)
+//	defer h.Stop()
+//
+// 设置 Options.UseRegistry 后，处理器还会作用于 law 注册表中的所有写入器。
+package signals
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/shengyanli1982/law"
+)
+
+// DefaultDrainTimeout 默认的停止超时
+const DefaultDrainTimeout = 5 * time.Second
+
+// Writer 处理器操作的写入器，*law.WriteAsyncer 实现了该接口
+type Writer interface {
+	Reopen() error
+	Flush() error
+	Stop()
+}
+
+// Options 处理器选项
+type Options struct {
+	// DrainTimeout 收到退出信号后等待所有写入器停止的最长时间，<= 0 时使用 DefaultDrainTimeout
+	DrainTimeout time.Duration
+
+	// OnError 重新打开或刷新失败时调用，为 nil 时忽略错误
+	OnError func(sig os.Signal, err error)
+
+	// AfterDrain 所有写入器停止（或超时）后调用，为 nil 时恢复信号的默认处理并重新发送该信号
+	AfterDrain func(sig os.Signal)
+
+	// UseRegistry 是否同时处理 law 注册表中的写入器（见 law.Register 和 law.Config.WithName）
+	UseRegistry bool
+}
+
+// Handler 已安装的信号处理器
+type Handler struct {
+	mu      sync.Mutex
+	writers []Writer
+	opts    Options
+	signals chan os.Signal
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Install 为 writers 安装信号处理器，opts 为 nil 时使用默认选项
+func Install(opts *Options, writers ...Writer) *Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	h := &Handler{
+		opts:    *opts,
+		signals: make(chan os.Signal, 4),
+		done:    make(chan struct{}),
+	}
+	if h.opts.DrainTimeout <= 0 {
+		h.opts.DrainTimeout = DefaultDrainTimeout
+	}
+	h.Add(writers...)
+
+	signal.Notify(h.signals, handledSignals()...)
+	go h.run()
+
+	return h
+}
+
+// Add 添加需要处理的写入器，可以在安装之后调用
+func (h *Handler) Add(writers ...Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, w := range writers {
+		if w != nil {
+			h.writers = append(h.writers, w)
+		}
+	}
+}
+
+// Stop 卸载信号处理器，不会停止写入器。可以重复调用。
+func (h *Handler) Stop() {
+	h.once.Do(func() {
+		signal.Stop(h.signals)
+		close(h.done)
+	})
+}
+
+// run 处理收到的信号
+func (h *Handler) run() {
+	for {
+		select {
+		case <-h.done:
+			return
+		case sig := <-h.signals:
+			switch {
+			case isReopenSignal(sig):
+				h.each(sig, Writer.Reopen)
+			case isFlushSignal(sig):
+				h.each(sig, Writer.Flush)
+			default:
+				h.drain(sig)
+				return
+			}
+		}
+	}
+}
+
+// snapshot 返回当前的写入器列表，开启 UseRegistry 时包括注册表中的写入器
+func (h *Handler) snapshot() []Writer {
+	h.mu.Lock()
+	writers := append([]Writer(nil), h.writers...)
+	h.mu.Unlock()
+
+	if h.opts.UseRegistry {
+		for _, wa := range law.Registered() {
+			if !contains(writers, wa) {
+				writers = append(writers, wa)
+			}
+		}
+	}
+	return writers
+}
+
+// contains 判断写入器是否已在列表中
+func contains(writers []Writer, w Writer) bool {
+	for _, existing := range writers {
+		if existing == w {
+			return true
+		}
+	}
+	return false
+}
+
+// each 对每个写入器执行 fn，不是文件输出或已经停止的写入器会被跳过
+func (h *Handler) each(sig os.Signal, fn func(Writer) error) {
+	for _, w := range h.snapshot() {
+		err := fn(w)
+		if err == nil || errors.Is(err, law.ErrorWriterNotReopenable) || errors.Is(err, law.ErrorWriteAsyncerIsClosed) {
+			continue
+		}
+		if h.opts.OnError != nil {
+			h.opts.OnError(sig, err)
+		}
+	}
+}
+
+// drain 并发停止所有写入器，最多等待 DrainTimeout，然后卸载处理器并重新发送信号
+func (h *Handler) drain(sig os.Signal) {
+	var wg sync.WaitGroup
+	for _, w := range h.snapshot() {
+		wg.Add(1)
+		go func(w Writer) {
+			defer wg.Done()
+			w.Stop()
+		}(w)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(h.opts.DrainTimeout)
+	defer timer.Stop()
+
+wait:
+	for {
+		select {
+		case <-stopped:
+			break wait
+		case <-timer.C:
+			break wait
+		case next := <-h.signals:
+			// 停止期间再次收到退出信号时不再等待
+			if !isReopenSignal(next) && !isFlushSignal(next) {
+				break wait
+			}
+		}
+	}
+
+	h.Stop()
+	if h.opts.AfterDrain != nil {
+		h.opts.AfterDrain(sig)
+		return
+	}
+	reraise(sig)
+}
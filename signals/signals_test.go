@@ -0,0 +1,96 @@
+//go:build !windows
+
+package signals
+
+import (
+	"bytes"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/law"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingWriter struct {
+	reopens, flushes, stops atomic.Int32
+	stopDelay               time.Duration
+}
+
+func (w *countingWriter) Reopen() error {
+	w.reopens.Add(1)
+	return law.ErrorWriterNotReopenable
+}
+
+func (w *countingWriter) Flush() error {
+	w.flushes.Add(1)
+	return nil
+}
+
+func (w *countingWriter) Stop() {
+	time.Sleep(w.stopDelay)
+	w.stops.Add(1)
+}
+
+func TestHandler_ReopenAndFlush(t *testing.T) {
+	w := &countingWriter{}
+	var errs atomic.Int32
+	h := Install(&Options{OnError: func(os.Signal, error) { errs.Add(1) }}, w)
+	defer h.Stop()
+
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool {
+		return w.reopens.Load() == 1 && w.flushes.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 不是文件输出的写入器不会被当作错误
+	assert.Equal(t, int32(0), errs.Load())
+	assert.Equal(t, int32(0), w.stops.Load())
+}
+
+func TestHandler_Registry(t *testing.T) {
+	aw := law.NewWriteAsyncer(bytes.NewBuffer(nil), law.NewConfig().WithName("signals-test"))
+	defer aw.Stop()
+	h := Install(&Options{UseRegistry: true})
+	defer h.Stop()
+
+	_, _ = aw.Write([]byte("pending\n"))
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool {
+		return aw.Stats().Flushes == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHandler_Drain(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	aw := law.NewWriteAsyncer(buff, nil)
+	slow := &countingWriter{stopDelay: time.Hour}
+
+	drained := make(chan os.Signal, 1)
+	start := time.Now()
+	h := Install(&Options{
+		DrainTimeout: 50 * time.Millisecond,
+		AfterDrain:   func(sig os.Signal) { drained <- sig },
+	}, aw)
+	h.Add(slow)
+
+	_, _ = aw.Write([]byte("tail\n"))
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case sig := <-drained:
+		assert.Equal(t, syscall.SIGTERM, sig)
+	case <-time.After(time.Second):
+		t.Fatal("drain did not finish")
+	}
+
+	// 停止慢的写入器超时后不再等待，其他写入器已经写出剩余的日志
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	aw.Stop()
+	assert.Equal(t, "tail\n", buff.String())
+	_, err := aw.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, law.ErrorWriteAsyncerIsClosed)
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package signals
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// handledSignals 处理器监听的信号
+func handledSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGTERM, syscall.SIGINT}
+}
+
+// isReopenSignal 判断是否是重新打开文件的信号
+func isReopenSignal(sig os.Signal) bool {
+	return sig == syscall.SIGHUP
+}
+
+// isFlushSignal 判断是否是刷新的信号
+func isFlushSignal(sig os.Signal) bool {
+	return sig == syscall.SIGUSR1
+}
+
+// reraise 恢复信号的默认处理并向当前进程重新发送该信号
+func reraise(sig os.Signal) {
+	signal.Reset(sig)
+	if s, ok := sig.(syscall.Signal); ok {
+		_ = syscall.Kill(os.Getpid(), s)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package signals
+
+import (
+	"os"
+	"syscall"
+)
+
+// handledSignals 处理器监听的信号
+func handledSignals() []os.Signal {
+	return []os.Signal{syscall.SIGTERM, os.Interrupt}
+}
+
+// isReopenSignal Windows 上没有重新打开文件的信号
+func isReopenSignal(os.Signal) bool {
+	return false
+}
+
+// isFlushSignal Windows 上没有刷新的信号
+func isFlushSignal(os.Signal) bool {
+	return false
+}
+
+// reraise Windows 上无法向自身重新发送信号，直接以退出码 2 退出
+func reraise(os.Signal) {
+	os.Exit(2)
+}
@@ -0,0 +1,161 @@
+//go:build go1.21
+
+package law
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// SlogHandler 是基于 WriteAsyncer 的异步 slog.Handler。
+// 调用方协程只负责级别过滤和复制 slog.Record，JSON/文本编码在轮询器协程上完成，
+// 编码结果写入缓冲池中的缓冲区。
+//
+// 属性值在编码前不会被复制，记录中引用的可变对象（map、切片、指针）在写入日志后不应再修改。
+// 实现了 slog.LogValuer 的属性值会在调用方协程上解析。
+type SlogHandler struct {
+	writer  *WriteAsyncer
+	handler slog.Handler
+	sink    *slogSink
+}
+
+// slogSink 是内置 slog.Handler 的输出目标，编码时指向当前记录的缓冲区。
+// 同一个 SlogHandler 派生出的所有处理器共享一个 slogSink。
+type slogSink struct {
+	mu   sync.Mutex
+	buff *bytes.Buffer
+}
+
+// Write 将编码结果追加到当前缓冲区
+func (s *slogSink) Write(p []byte) (int, error) {
+	return s.buff.Write(p)
+}
+
+// NewSlogJSONHandler 创建以 JSON 格式编码的异步 slog.Handler，opts 为 nil 时使用默认选项
+func NewSlogJSONHandler(w *WriteAsyncer, opts *slog.HandlerOptions) *SlogHandler {
+	sink := &slogSink{}
+	return &SlogHandler{writer: w, handler: slog.NewJSONHandler(sink, opts), sink: sink}
+}
+
+// NewSlogTextHandler 创建以 key=value 文本格式编码的异步 slog.Handler，opts 为 nil 时使用默认选项
+func NewSlogTextHandler(w *WriteAsyncer, opts *slog.HandlerOptions) *SlogHandler {
+	sink := &slogSink{}
+	return &SlogHandler{writer: w, handler: slog.NewTextHandler(sink, opts), sink: sink}
+}
+
+// Enabled 判断指定级别的日志是否需要记录
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle 复制记录并入队，编码延迟到轮询器协程上执行
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	record := slogRecordPool.Get().(*slogRecord)
+	record.handler = h.handler
+	record.sink = h.sink
+	record.record = resolveSlogRecord(r)
+
+	return h.writer.WriteRecord(record)
+}
+
+// WithAttrs 返回附加了属性的新处理器
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &SlogHandler{writer: h.writer, handler: h.handler.WithAttrs(attrs), sink: h.sink}
+}
+
+// WithGroup 返回在指定分组下记录属性的新处理器
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{writer: h.writer, handler: h.handler.WithGroup(name), sink: h.sink}
+}
+
+// slogRecordPool 复用等待编码的 slog 记录
+var slogRecordPool = sync.Pool{
+	New: func() any {
+		return &slogRecord{}
+	},
+}
+
+// slogRecord 是等待编码的 slog 记录
+type slogRecord struct {
+	handler slog.Handler
+	sink    *slogSink
+	record  slog.Record
+}
+
+// EncodeTo 使用内置处理器将记录编码到 buff。
+// 记录在轮询器协程上编码，不会携带调用方的 context。
+func (r *slogRecord) EncodeTo(buff *bytes.Buffer) error {
+	r.sink.mu.Lock()
+	defer r.sink.mu.Unlock()
+
+	r.sink.buff = buff
+	err := r.handler.Handle(context.Background(), r.record)
+	r.sink.buff = nil
+	return err
+}
+
+// Release 清空记录并归还到对象池
+func (r *slogRecord) Release() {
+	*r = slogRecord{}
+	slogRecordPool.Put(r)
+}
+
+// resolveSlogRecord 复制记录，并在调用方协程上解析 slog.LogValuer 属性值
+func resolveSlogRecord(r slog.Record) slog.Record {
+	needResolve := false
+	r.Attrs(func(a slog.Attr) bool {
+		if isSlogValuer(a.Value) {
+			needResolve = true
+			return false
+		}
+		return true
+	})
+
+	if !needResolve {
+		return r.Clone()
+	}
+
+	resolved := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		resolved.AddAttrs(resolveSlogAttr(a))
+		return true
+	})
+	return resolved
+}
+
+// isSlogValuer 判断属性值或其分组成员中是否包含 slog.LogValuer
+func isSlogValuer(v slog.Value) bool {
+	switch v.Kind() {
+	case slog.KindLogValuer:
+		return true
+	case slog.KindGroup:
+		for _, a := range v.Group() {
+			if isSlogValuer(a.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveSlogAttr 递归解析属性中的 slog.LogValuer
+func resolveSlogAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, 0, len(group))
+		for _, ga := range group {
+			attrs = append(attrs, resolveSlogAttr(ga))
+		}
+		a.Value = slog.GroupValue(attrs...)
+	}
+	return a
+}
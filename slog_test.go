@@ -0,0 +1,100 @@
+//go:build go1.21
+
+package law
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type secret string
+
+func (s secret) LogValue() slog.Value {
+	return slog.StringValue("***")
+}
+
+func TestSlogHandler_JSON(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, nil)
+
+	logger := slog.New(NewSlogJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger = logger.With("service", "api").WithGroup("req")
+
+	logger.Debug("filtered")
+	logger.Info("hello", "id", 1, "token", secret("abc"))
+	logger.Warn("world", slog.Group("user", "name", "bob"))
+	w.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var first map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "hello", first["msg"])
+	assert.Equal(t, "INFO", first["level"])
+	assert.Equal(t, "api", first["service"])
+	assert.Equal(t, map[string]any{"id": float64(1), "token": "***"}, first["req"])
+
+	var second map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, map[string]any{"user": map[string]any{"name": "bob"}}, second["req"])
+}
+
+func TestSlogHandler_Text(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w := NewWriteAsyncer(buff, nil)
+
+	logger := slog.New(NewSlogTextHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("hello", "id", 1)
+	w.Stop()
+
+	assert.Equal(t, "level=INFO msg=hello id=1\n", buff.String())
+}
+
+func TestSlogHandler_CustomQueue(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().WithQueue(&sliceQueue{})
+	w := NewWriteAsyncer(buff, conf)
+
+	logger := slog.New(NewSlogTextHandler(w, nil))
+	logger.Info("hello")
+	w.Stop()
+
+	assert.Contains(t, buff.String(), "msg=hello")
+	assert.Equal(t, uint64(1), w.Stats().WrittenRecords)
+}
+
+func TestSlogHandler_Closed(t *testing.T) {
+	w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+	w.Stop()
+
+	h := NewSlogJSONHandler(w, nil)
+	err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0))
+	assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+}
+
+func BenchmarkSlogHandler(b *testing.B) {
+	w := NewWriteAsyncer(bytes.NewBuffer(make([]byte, 0, 1024)), nil)
+	defer w.Stop()
+
+	logger := slog.New(NewSlogJSONHandler(w, nil))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("hello", "id", i, "path", "/api/v1/users")
+	}
+}
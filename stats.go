@@ -0,0 +1,111 @@
+package law
+
+import (
+	"time"
+
+	"github.com/shengyanli1982/law/internal/metrics"
+)
+
+// HistogramBucket 直方图中的一个非空桶
+type HistogramBucket struct {
+	UpperBound time.Duration // 桶内耗时的上界（包含）
+	Count      uint64        // 落入该桶的次数（非累计）
+}
+
+// HistogramSnapshot 耗时直方图的只读快照
+type HistogramSnapshot struct {
+	Count   uint64            // 观测次数
+	Sum     time.Duration     // 耗时总和
+	Max     time.Duration     // 最大耗时
+	P50     time.Duration     // 50 分位耗时
+	P90     time.Duration     // 90 分位耗时
+	P99     time.Duration     // 99 分位耗时
+	Buckets []HistogramBucket // 按上界升序排列的非空桶
+
+	raw metrics.Snapshot
+}
+
+// newHistogramSnapshot 将内部直方图快照转换为公开快照
+func newHistogramSnapshot(h *metrics.Histogram) HistogramSnapshot {
+	if h == nil {
+		return HistogramSnapshot{}
+	}
+
+	raw := h.Snapshot()
+	s := HistogramSnapshot{
+		Count:   raw.Count,
+		Sum:     time.Duration(raw.Sum),
+		Max:     time.Duration(raw.Max),
+		P50:     time.Duration(raw.Quantile(0.5)),
+		P90:     time.Duration(raw.Quantile(0.9)),
+		P99:     time.Duration(raw.Quantile(0.99)),
+		Buckets: make([]HistogramBucket, 0, len(raw.Buckets)),
+		raw:     raw,
+	}
+	for _, b := range raw.Buckets {
+		s.Buckets = append(s.Buckets, HistogramBucket{UpperBound: time.Duration(b.UpperBound), Count: b.Count})
+	}
+	return s
+}
+
+// Quantile 返回分位数 q（0 <= q <= 1）的估计耗时，相对误差不超过 12.5%
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	return time.Duration(s.raw.Quantile(q))
+}
+
+// Mean 返回平均耗时
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// LatencyStats 延迟统计快照
+type LatencyStats struct {
+	// QueueDelay 从 Write 入队到数据到达底层 io.Writer 的延迟
+	QueueDelay HistogramSnapshot
+
+	// FlushDuration 每次刷新底层 io.Writer 的耗时
+	FlushDuration HistogramSnapshot
+}
+
+// Stats 写入器运行状态快照
+type Stats struct {
+	Running           bool            // 写入器是否在运行
+	QueueLength       int             // 队列中等待写入的记录数，队列不支持 Len 时为 -1
+	BufferedBytes     int             // 缓冲写入器中尚未刷新的字节数
+	EnqueuedRecords   uint64          // 被 Write 接收并入队的记录数
+	EnqueuedBytes     uint64          // 被 Write 接收并入队的字节数，延迟编码的记录不计入
+	WrittenRecords    uint64          // 成功写入缓冲写入器的记录数
+	WrittenBytes      uint64          // 成功写入缓冲写入器的字节数
+	FailedRecords     uint64          // 写入失败的记录数
+	Flushes           uint64          // 刷新底层 io.Writer 的次数
+	FlushErrors       uint64          // 刷新失败的次数
+	LastFlushAt       time.Time       // 最近一次成功刷新的时间，尚未刷新时为零值
+	RejectedRecords   uint64          // 因超过最大长度被拒绝的记录数
+	TruncatedRecords  uint64          // 因超过最大长度被截断的记录数
+	SplitRecords      uint64          // 因超过最大长度被拆分的记录数
+	FilteredRecords   uint64          // 被转换阶段丢弃的记录数
+	TransformErrors   uint64          // 转换阶段返回错误的次数
+	SuppressedRecords uint64          // 被采样器丢弃的记录数
+	RepeatedRecords   uint64          // 被合并为重复摘要的记录数
+	Paused            bool            // 写入器是否已暂停
+	DiscardedRecords  uint64          // 暂停期间停止时按 StopDiscard 丢弃的记录数
+	Latency           LatencyStats    // 延迟统计，未开启 WithLatencyStats 时为零值
+	BufferPool        BufferPoolStats // 缓冲池统计，共享缓冲池时为所有写入器的总和
+}
+
+// latencyRecorder 延迟统计的直方图集合
+type latencyRecorder struct {
+	queueDelay    *metrics.Histogram
+	flushDuration *metrics.Histogram
+}
+
+// newLatencyRecorder 创建延迟统计的直方图集合
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{
+		queueDelay:    metrics.NewHistogram(),
+		flushDuration: metrics.NewHistogram(),
+	}
+}
@@ -0,0 +1,102 @@
+package law
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAsyncer_LatencyStats(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+
+		_, err := w.Write([]byte("hello"))
+		assert.Nil(t, err)
+		w.Stop()
+
+		stats := w.LatencyStats()
+		assert.Equal(t, uint64(0), stats.QueueDelay.Count)
+		assert.Equal(t, uint64(0), stats.FlushDuration.Count)
+	})
+
+	t.Run("records queue delay and flush duration", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		conf := NewConfig().
+			WithLatencyStats(true).
+			WithHeartbeatInterval(10 * time.Millisecond).
+			WithIdleTimeout(50 * time.Millisecond)
+		w := NewWriteAsyncer(buff, conf)
+
+		for i := 0; i < 10; i++ {
+			_, err := w.Write([]byte("hello"))
+			assert.Nil(t, err)
+		}
+		w.Stop()
+
+		assert.Equal(t, 50, buff.Len())
+
+		stats := w.LatencyStats()
+		assert.Equal(t, uint64(10), stats.QueueDelay.Count)
+		assert.Greater(t, stats.QueueDelay.Max, time.Duration(0))
+		assert.LessOrEqual(t, stats.QueueDelay.P50, stats.QueueDelay.P99)
+		assert.LessOrEqual(t, stats.QueueDelay.P99, stats.QueueDelay.Max)
+		assert.GreaterOrEqual(t, stats.FlushDuration.Count, uint64(1))
+	})
+
+	t.Run("content larger than buffer reaches writer directly", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		conf := NewConfig().WithLatencyStats(true).WithBufferSize(16)
+		w := NewWriteAsyncer(buff, conf)
+
+		_, err := w.Write(largeBytes)
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, uint64(1), w.LatencyStats().QueueDelay.Count)
+	})
+}
+
+func TestWriteAsyncer_Stats(t *testing.T) {
+	t.Run("counts written and flushed records", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+
+		for i := 0; i < 10; i++ {
+			_, err := w.Write([]byte("hello"))
+			assert.Nil(t, err)
+		}
+
+		stats := w.Stats()
+		assert.True(t, stats.Running)
+		assert.Equal(t, uint64(10), stats.EnqueuedRecords)
+		assert.Equal(t, uint64(50), stats.EnqueuedBytes)
+		assert.GreaterOrEqual(t, stats.QueueLength, 0)
+
+		w.Stop()
+
+		stats = w.Stats()
+		assert.False(t, stats.Running)
+		assert.Equal(t, 0, stats.QueueLength)
+		assert.Equal(t, 0, stats.BufferedBytes)
+		assert.Equal(t, uint64(10), stats.WrittenRecords)
+		assert.Equal(t, uint64(50), stats.WrittenBytes)
+		assert.Equal(t, uint64(1), stats.Flushes)
+		assert.False(t, stats.LastFlushAt.IsZero())
+	})
+
+	t.Run("counts failed records", func(t *testing.T) {
+		conf := NewConfig().WithBufferSize(16)
+		w := NewWriteAsyncer(&faultyWriter{}, conf)
+
+		_, err := w.Write(largeBytes)
+		assert.Nil(t, err)
+		w.Stop()
+
+		stats := w.Stats()
+		assert.Equal(t, uint64(1), stats.FailedRecords)
+		assert.Equal(t, uint64(0), stats.WrittenRecords)
+	})
+}
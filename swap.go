@@ -0,0 +1,86 @@
+package law
+
+import (
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/shengyanli1982/law/crypt"
+)
+
+// SetWriter 将底层 io.Writer 替换为 w，返回原来的 io.Writer。
+// 替换在轮询器协程上执行：先写出队列中已有的记录并刷新到原来的 io.Writer，再把缓冲写入器切换到 w，
+// 因此已入队的数据不会丢失，也不需要重新创建写入器。开启加密时原来的流会写出结束块，w 上开始新的流。
+// 设置了 WithCloseReplacedWriter 时会关闭实现了 io.Closer 的原 io.Writer。
+// 刷新或关闭失败时仍然会切换到 w，并返回第一个错误。
+func (wa *WriteAsyncer) SetWriter(w io.Writer) (old io.Writer, err error) {
+	if w == nil {
+		return nil, ErrorWriterIsNil
+	}
+	if !wa.state.IsRunning() {
+		return nil, ErrorWriteAsyncerIsClosed
+	}
+
+	err = wa.call(func() error {
+		var swapErr error
+		old, swapErr = wa.swapWriter(w, wa.conf().closeReplaced)
+		return swapErr
+	})
+	return old, err
+}
+
+// Reopen 按路径重新打开当前写入的文件并切换过去，原来的文件会被关闭，重新打开的文件在 Stop 时关闭。
+// 用于在 logrotate 重命名日志文件后（例如收到 SIGHUP 时）重新打开同名文件，而不需要 copytruncate。
+// 新文件以追加方式打开，不存在时以原文件的权限创建；打开失败时继续写入原来的文件并返回错误。
+// 当前的 io.Writer 不是按路径打开的 *os.File（包括标准输出和标准错误）时返回 ErrorWriterNotReopenable。
+func (wa *WriteAsyncer) Reopen() error {
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+
+	return wa.call(func() error {
+		f, ok := wa.writer.(*os.File)
+		if !ok || f == os.Stdout || f == os.Stderr || f.Name() == "" {
+			return ErrorWriterNotReopenable
+		}
+
+		mode := fs.FileMode(0o644)
+		if info, err := f.Stat(); err == nil {
+			mode = info.Mode().Perm()
+		}
+		reopened, err := os.OpenFile(f.Name(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+
+		_, err = wa.swapWriter(reopened, true)
+		wa.ownsWriter = true
+		return err
+	})
+}
+
+// swapWriter 刷新缓冲写入器并切换到 w，只在轮询器协程上调用
+func (wa *WriteAsyncer) swapWriter(w io.Writer, closeOld bool) (io.Writer, error) {
+	err := wa.poller.Sync()
+
+	if wa.encrypter != nil {
+		if closeErr := wa.encrypter.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		wa.encrypter = crypt.NewWriter(w, wa.conf().encryptionKeys)
+		wa.bufferedWriter.Reset(wa.encrypter)
+	} else {
+		wa.bufferedWriter.Reset(w)
+	}
+	wa.counters.BufferedBytes.Store(0)
+
+	// 由 Reopen 打开的文件总是关闭
+	old, owned := wa.writer, wa.ownsWriter
+	wa.writer, wa.ownsWriter = w, false
+	if closer, ok := old.(io.Closer); ok && (closeOld || owned) {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return old, err
+}
@@ -0,0 +1,83 @@
+package law
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWriteAsyncer_SetWriter(t *testing.T) {
+	first, second := &closeRecorder{}, &closeRecorder{}
+	w := NewWriteAsyncer(first, NewConfig().WithCloseReplacedWriter(true))
+
+	// 替换前入队的记录写入原来的 io.Writer
+	_, _ = w.Write([]byte("before\n"))
+	old, err := w.SetWriter(second)
+	assert.Nil(t, err)
+	assert.Equal(t, first, old)
+	assert.True(t, first.closed)
+
+	_, _ = w.Write([]byte("after\n"))
+	w.Stop()
+
+	assert.Equal(t, "before\n", first.String())
+	assert.Equal(t, "after\n", second.String())
+	assert.False(t, second.closed)
+
+	_, err = w.SetWriter(first)
+	assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+}
+
+func TestWriteAsyncer_SetWriterKeepsOld(t *testing.T) {
+	first := &closeRecorder{}
+	w := NewWriteAsyncer(first, nil)
+	defer w.Stop()
+
+	_, err := w.SetWriter(nil)
+	assert.ErrorIs(t, err, ErrorWriterIsNil)
+
+	old, err := w.SetWriter(&bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, first, old)
+	assert.False(t, first.closed)
+
+	assert.ErrorIs(t, w.Reopen(), ErrorWriterNotReopenable)
+}
+
+func TestWriteAsyncer_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.Nil(t, err)
+
+	w := NewWriteAsyncer(f, nil)
+	_, _ = w.Write([]byte("rotated\n"))
+
+	// 模拟 logrotate 重命名文件后发送 SIGHUP
+	assert.Nil(t, os.Rename(path, path+".1"))
+	assert.Nil(t, w.Reopen())
+	_, _ = w.Write([]byte("current\n"))
+	w.Stop()
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	assert.Equal(t, "rotated\n", string(rotated))
+	assert.Equal(t, "current\n", string(current))
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
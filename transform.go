@@ -0,0 +1,32 @@
+package law
+
+import (
+	"bytes"
+)
+
+// Transform 是在轮询器协程上执行的记录转换阶段。
+//
+// in 为上一阶段输出的记录，out 是从缓冲池获取的空缓冲区。阶段把转换结果写入 out 并返回 keep = true；
+// 返回 keep = true 但没有向 out 写入任何内容时，记录保持不变，因此只做过滤的阶段不需要复制记录。
+// 返回 keep = false 时记录被丢弃，计入 Stats.FilteredRecords；返回错误时记录被丢弃，
+// 计入 Stats.TransformErrors，并通过 Callback.OnWriteFailed 通知。
+//
+// in 和 out 只在调用期间有效，阶段不应持有它们。
+type Transform func(in []byte, out *bytes.Buffer) (keep bool, err error)
+
+// FilterTransform 返回只保留 keep 返回 true 的记录的转换阶段
+func FilterTransform(keep func(record []byte) bool) Transform {
+	return func(in []byte, _ *bytes.Buffer) (bool, error) {
+		return keep(in), nil
+	}
+}
+
+// PrefixTransform 返回在每条记录前写入前缀的转换阶段，prefix 负责把前缀写入 out，
+// 例如时间戳、主机名或进程号
+func PrefixTransform(prefix func(out *bytes.Buffer)) Transform {
+	return func(in []byte, out *bytes.Buffer) (bool, error) {
+		prefix(out)
+		out.Write(in)
+		return true, nil
+	}
+}
@@ -0,0 +1,79 @@
+package law
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failedContentCallback 记录写入失败的内容
+type failedContentCallback struct {
+	mu       sync.Mutex
+	contents []string
+}
+
+func (c *failedContentCallback) OnWriteFailed(content []byte, _ error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contents = append(c.contents, string(content))
+}
+
+// upperTransform 将记录转换为大写
+func upperTransform(in []byte, out *bytes.Buffer) (bool, error) {
+	out.Write(bytes.ToUpper(in))
+	return true, nil
+}
+
+func TestWriteAsyncer_Transforms(t *testing.T) {
+	t.Run("ordered stages", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		conf := NewConfig().
+			WithTransforms(FilterTransform(func(r []byte) bool { return !bytes.HasPrefix(r, []byte("debug")) })).
+			WithTransforms(upperTransform, PrefixTransform(func(out *bytes.Buffer) { out.WriteString("app: ") }))
+		w := NewWriteAsyncer(buff, conf)
+
+		_, _ = w.Write([]byte("hello\n"))
+		_, _ = w.Write([]byte("debug details\n"))
+		_ = w.WriteRecord(RecordFunc(func(b *bytes.Buffer) error {
+			b.WriteString("record\n")
+			return nil
+		}))
+		w.Stop()
+
+		assert.Equal(t, "app: HELLO\napp: RECORD\n", buff.String())
+		assert.Equal(t, uint64(1), w.Stats().FilteredRecords)
+		assert.Equal(t, uint64(2), w.Stats().WrittenRecords)
+	})
+
+	t.Run("stage error", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		cb := &failedContentCallback{}
+		boom := errors.New("boom")
+		conf := NewConfig().WithCallback(cb).WithTransforms(func(in []byte, out *bytes.Buffer) (bool, error) {
+			if strings.Contains(string(in), "bad") {
+				return false, boom
+			}
+			return true, nil
+		})
+		w := NewWriteAsyncer(buff, conf)
+
+		_, _ = w.Write([]byte("bad\n"))
+		_, _ = w.Write([]byte("good\n"))
+		w.Stop()
+
+		assert.Equal(t, "good\n", buff.String())
+		assert.Equal(t, uint64(1), w.Stats().TransformErrors)
+		assert.ErrorIs(t, w.RecentErrors()[0].Err, boom)
+		assert.Equal(t, []string{"bad\n"}, cb.contents)
+	})
+
+	t.Run("config copies stages", func(t *testing.T) {
+		conf := NewConfig().WithTransforms(upperTransform, nil)
+		assert.Len(t, conf.Transforms(), 1)
+		assert.Len(t, conf.clone().Transforms(), 1)
+	})
+}
@@ -0,0 +1,85 @@
+package law
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shengyanli1982/law/frame"
+	wr "github.com/shengyanli1982/law/internal/writer"
+)
+
+// ErrorInvalidConfig 配置中存在无效或相互矛盾的字段
+var ErrorInvalidConfig = errors.New("invalid config")
+
+// Validate 检查配置，返回所有无效或相互矛盾的字段，每个错误都包装了 ErrorInvalidConfig 并以对应的 With 方法开头。
+// 配置有效时返回 nil。NewWriteAsyncer 会把这些值替换为默认值，NewWriteAsyncerE 则返回该错误。
+func (c *Config) Validate() error {
+	if c == nil {
+		return ErrorConfigIsNil
+	}
+
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrorInvalidConfig}, args...)...))
+	}
+
+	if c.buffSize <= 0 {
+		invalid("WithBufferSize: %d must be greater than 0", c.buffSize)
+	}
+	if c.heartbeatInterval <= 0 {
+		invalid("WithHeartbeatInterval: %v must be greater than 0", c.heartbeatInterval)
+	}
+	if c.idleTimeout <= 0 {
+		invalid("WithIdleTimeout: %v must be greater than 0", c.idleTimeout)
+	}
+	if c.heartbeatInterval > 0 && c.idleTimeout > 0 && c.idleTimeout < c.heartbeatInterval {
+		invalid("WithIdleTimeout: %v is shorter than the heartbeat interval %v", c.idleTimeout, c.heartbeatInterval)
+	}
+	for _, size := range c.sizeClasses {
+		if size <= 0 {
+			invalid("WithBufferSizeClasses: class %d must be greater than 0", size)
+			break
+		}
+	}
+	if c.bufferPool != nil && (len(c.sizeClasses) > 0 || c.poolBudget != 0) {
+		invalid("WithBufferPool: size classes and pool budget have no effect with a shared buffer pool")
+	}
+	if c.oversizeMode < OversizeReject || c.oversizeMode > OversizeSplit {
+		invalid("WithMaxRecordSize: unknown oversize mode %d", int(c.oversizeMode))
+	}
+	if c.oversizeMode == OversizeSplit && c.maxRecordSize > 0 && c.maxRecordSize < wr.MinSplitSize {
+		invalid("WithMaxRecordSize: %d is smaller than %d, the minimum for OversizeSplit", c.maxRecordSize, wr.MinSplitSize)
+	}
+	if c.framing != 0 && c.framing|frame.LengthPrefix != c.framing.Normalize() {
+		invalid("WithFraming: unknown flags %#x", byte(c.framing))
+	}
+	if c.framing == 0 && c.frameSeqStart != 0 {
+		invalid("WithFrameSequenceStart: framing is not enabled")
+	}
+	if c.dedupWindow < 0 {
+		invalid("WithDedup: %v must not be negative", c.dedupWindow)
+	}
+	if c.pausedStop != StopDrain && c.pausedStop != StopDiscard {
+		invalid("WithPausedStopPolicy: unknown policy %d", int(c.pausedStop))
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewWriteAsyncerE 创建新的异步写入器，与 NewWriteAsyncer 不同，配置无效时不替换为默认值，而是返回 Config.Validate 的错误；
+// 通过 WithName 设置的名称已被使用时返回 ErrorNameInUse。conf 为 nil 时使用默认配置。
+func NewWriteAsyncerE(writer io.Writer, conf *Config) (*WriteAsyncer, error) {
+	if conf != nil {
+		if err := conf.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	wa, err := newWriteAsyncer(writer, conf)
+	if err != nil {
+		wa.Stop()
+		return nil, err
+	}
+	return wa, nil
+}
@@ -0,0 +1,96 @@
+package law
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/law/frame"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.Nil(t, NewConfig().Validate())
+	assert.ErrorIs(t, (*Config)(nil).Validate(), ErrorConfigIsNil)
+
+	conf := NewConfig().
+		WithBufferSize(-1).
+		WithHeartbeatInterval(0).
+		WithIdleTimeout(-time.Second).
+		WithBufferSizeClasses(128, 0).
+		WithMaxRecordSize(16, OversizeSplit).
+		WithFraming(frame.Flags(0x80)).
+		WithDedup(-time.Second).
+		WithPausedStopPolicy(StopPolicy(9))
+	err := conf.Validate()
+	assert.ErrorIs(t, err, ErrorInvalidConfig)
+
+	// 报告所有无效的字段，而不是只报告第一个
+	var joined interface{ Unwrap() []error }
+	assert.True(t, errors.As(err, &joined))
+	assert.Len(t, joined.Unwrap(), 8)
+	for _, setter := range []string{
+		"WithBufferSize", "WithHeartbeatInterval", "WithIdleTimeout", "WithBufferSizeClasses",
+		"WithMaxRecordSize", "WithFraming", "WithDedup", "WithPausedStopPolicy",
+	} {
+		assert.Contains(t, err.Error(), setter)
+	}
+
+	// 相互矛盾的字段
+	err = NewConfig().
+		WithHeartbeatInterval(time.Second).
+		WithIdleTimeout(time.Millisecond).
+		WithBufferPool(NewBufferPool(nil, 0)).
+		WithBufferPoolBudget(1024).
+		WithFrameSequenceStart(10).
+		Validate()
+	assert.ErrorIs(t, err, ErrorInvalidConfig)
+	assert.Contains(t, err.Error(), "WithIdleTimeout")
+	assert.Contains(t, err.Error(), "WithBufferPool")
+	assert.Contains(t, err.Error(), "WithFrameSequenceStart")
+}
+
+func TestNewWriteAsyncerE(t *testing.T) {
+	w, err := NewWriteAsyncerE(&bytes.Buffer{}, NewConfig().WithBufferSize(0))
+	assert.Nil(t, w)
+	assert.ErrorIs(t, err, ErrorInvalidConfig)
+
+	// 宽松的构造函数仍然替换为默认值
+	w = NewWriteAsyncer(&bytes.Buffer{}, NewConfig().WithBufferSize(0))
+	assert.Equal(t, DefaultBufferSize, w.Config().BufferSize())
+	w.Stop()
+
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	w, err = NewWriteAsyncerE(buff, NewConfig().WithName("validate-e"))
+	assert.Nil(t, err)
+	_, _ = w.Write([]byte("hello\n"))
+
+	dup, err := NewWriteAsyncerE(&bytes.Buffer{}, NewConfig().WithName("validate-e"))
+	assert.Nil(t, dup)
+	assert.ErrorIs(t, err, ErrorNameInUse)
+	assert.Equal(t, w, Lookup("validate-e"))
+
+	w.Stop()
+	assert.Equal(t, "hello\n", buff.String())
+
+	w, err = NewWriteAsyncerE(&bytes.Buffer{}, nil)
+	assert.Nil(t, err)
+	w.Stop()
+}
+
+func TestWriteAsyncer_LongHeartbeat(t *testing.T) {
+	buff := bytes.NewBuffer(make([]byte, 0, 1024))
+	conf := NewConfig().WithHeartbeatInterval(1100 * time.Millisecond).WithIdleTimeout(1100 * time.Millisecond)
+	w, err := NewWriteAsyncerE(buff, conf)
+	assert.Nil(t, err)
+
+	// 心跳间隔超过一秒时每次心跳都更新时钟
+	_, _ = w.Write([]byte("tick\n"))
+	assert.Eventually(t, func() bool {
+		return w.Stats().Flushes == 1
+	}, 4*time.Second, 10*time.Millisecond)
+
+	w.Stop()
+	assert.Equal(t, "tick\n", buff.String())
+}
@@ -2,27 +2,41 @@ package law
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"io"
 	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/shengyanli1982/law/crypt"
+	"github.com/shengyanli1982/law/frame"
+	"github.com/shengyanli1982/law/internal/metrics"
 	"github.com/shengyanli1982/law/internal/poller"
+	iq "github.com/shengyanli1982/law/internal/queue"
+	ir "github.com/shengyanli1982/law/internal/registry"
+	"github.com/shengyanli1982/law/internal/utils"
 	wr "github.com/shengyanli1982/law/internal/writer"
 )
 
+// recentErrorsSize 保留的最近失败记录数
+const recentErrorsSize = 16
+
 // 错误定义
 var (
 	ErrorWriteAsyncerIsClosed = errors.New("write asyncer is closed")
 	ErrorWriteContentIsNil    = errors.New("write content is nil")
+	ErrorRecordTooLarge       = wr.ErrorRecordTooLarge
+	ErrorWriterIsNil          = ir.ErrorWriterIsNil
+	ErrorWriterNotReopenable  = errors.New("writer is not a reopenable file")
 )
 
 // WriteAsyncer 异步写入器结构体
 type WriteAsyncer struct {
-	config         *Config
-	queue          Queue
+	config         atomic.Pointer[Config]
+	queue          poller.Queue[wr.Element]
 	writer         io.Writer
 	bufferedWriter *bufio.Writer
 	poller         *poller.Poller
@@ -33,33 +47,71 @@ type WriteAsyncer struct {
 	wg             sync.WaitGroup
 	state          *wr.Status
 	bufferpool     *wr.BufferPool
+	pool           *BufferPool
+	latency        *latencyRecorder
+	counters       *metrics.Counters
+	errors         *metrics.ErrorRing
+	limiter        *wr.SizeLimiter
+	encrypter      *crypt.Writer
+	ownsWriter     bool
 }
 
-// NewWriteAsyncer 创建新的异步写入器
+// NewWriteAsyncer 创建新的异步写入器，配置中的无效值被替换为默认值
 func NewWriteAsyncer(writer io.Writer, conf *Config) *WriteAsyncer {
+	wa, err := newWriteAsyncer(writer, conf)
+	if err != nil {
+		wa.errors.Add(err, 0)
+	}
+	return wa
+}
+
+// newWriteAsyncer 创建并启动异步写入器，返回加入注册表时的错误
+func newWriteAsyncer(writer io.Writer, conf *Config) (*WriteAsyncer, error) {
 	if writer == nil {
 		writer = os.Stdout
 	}
 
 	conf = isConfigValid(conf)
-	queue := conf.queue
+
+	var queue poller.Queue[wr.Element]
+	if conf.queue != nil {
+		queue = &bufferQueue{queue: conf.queue}
+	} else {
+		queue = iq.NewMPSCQueue[wr.Element]()
+	}
 
 	wa := &WriteAsyncer{
-		config:         conf,
-		queue:          queue,
-		writer:         writer,
-		bufferedWriter: bufio.NewWriterSize(writer, conf.buffSize),
-		state:          wr.NewStatus(),
-		timer:          atomic.Int64{},
-		once:           sync.Once{},
-		wg:             sync.WaitGroup{},
-		bufferpool:     wr.NewBufferPool(),
+		queue:    queue,
+		writer:   writer,
+		state:    wr.NewStatus(),
+		timer:    atomic.Int64{},
+		once:     sync.Once{},
+		wg:       sync.WaitGroup{},
+		counters: metrics.NewCounters(),
+		errors:   metrics.NewErrorRing(recentErrorsSize),
 	}
 
+	// 加密写入器位于缓冲写入器之下，每次刷新产生一个加密块
+	if conf.encryptionKeys != nil {
+		wa.encrypter = crypt.NewWriter(writer, conf.encryptionKeys)
+		wa.bufferedWriter = bufio.NewWriterSize(wa.encrypter, conf.buffSize)
+	} else {
+		wa.bufferedWriter = bufio.NewWriterSize(writer, conf.buffSize)
+	}
+
+	if conf.bufferPool != nil {
+		wa.bufferpool = conf.bufferPool.pool
+	} else {
+		wa.bufferpool = wr.NewBufferPoolWithClasses(conf.sizeClasses, conf.poolBudget)
+	}
+	wa.pool = &BufferPool{pool: wa.bufferpool}
+	wa.limiter = newSizeLimiter(conf, wa)
+
+	wa.config.Store(conf)
 	wa.ctx, wa.cancel = context.WithCancel(context.Background())
 	wa.state.SetRunning(true)
 
-	wa.poller = poller.NewPoller(&poller.Config{
+	pollerConf := &poller.Config{
 		Queue:             queue,
 		Writer:            wa.bufferedWriter,
 		Callback:          conf.callback,
@@ -67,12 +119,40 @@ func NewWriteAsyncer(writer io.Writer, conf *Config) *WriteAsyncer {
 		Timer:             &wa.timer,
 		HeartbeatInterval: conf.heartbeatInterval,
 		IdleTimeout:       conf.idleTimeout,
-	})
+		Counters:          wa.counters,
+		Errors:            wa.errors,
+		Limiter:           wa.limiter,
+		EnsureNewline:     conf.ensureNewline,
+		DedupWindow:       conf.dedupWindow,
+	}
+	for _, stage := range conf.transforms {
+		pollerConf.Transforms = append(pollerConf.Transforms, wr.Transform(stage))
+	}
+	if conf.audit != nil {
+		pollerConf.Transforms = append(pollerConf.Transforms, conf.audit.Transform)
+	}
+	if conf.sampler != nil {
+		conf.sampler.start(time.Now())
+		pollerConf.TickHooks = append(pollerConf.TickHooks, conf.sampler.writeSummary)
+		pollerConf.StopHooks = append(pollerConf.StopHooks, conf.sampler.writeFinalSummary)
+	}
+	if conf.framing != 0 {
+		pollerConf.Framer = frame.NewEncoder(conf.framing, conf.frameSeqStart)
+	}
+	if conf.latencyStats {
+		wa.latency = newLatencyRecorder()
+		pollerConf.QueueDelay = wa.latency.queueDelay
+		pollerConf.FlushDuration = wa.latency.flushDuration
+	}
+	wa.poller = poller.NewPoller(pollerConf)
 
 	wa.wg.Add(1)
 	go wa.poller.Run(wa.ctx, &wa.wg)
 
-	return wa
+	if conf.name != "" {
+		return wa, writerRegistry.add(conf.name, wa)
+	}
+	return wa, nil
 }
 
 // Stop 停止异步写入器
@@ -81,9 +161,25 @@ func (wa *WriteAsyncer) Stop() {
 		wa.state.SetRunning(false)
 		wa.cancel()
 		wa.wg.Wait()
-		wa.poller.CleanQueue()
-		_ = wa.bufferedWriter.Flush()
+		if wa.poller.Paused() && wa.conf().pausedStop == StopDiscard {
+			wa.counters.DiscardedRecords.Add(uint64(wa.poller.DiscardQueue()))
+		} else {
+			wa.poller.CleanQueue()
+		}
+		// 写出采样摘要等最后的记录，停止前被抑制的记录数不会丢失
+		wa.poller.RunStopHooks()
+		_ = wa.poller.Sync()
+		if wa.encrypter != nil {
+			if err := wa.encrypter.Close(); err != nil {
+				wa.errors.Add(err, 0)
+			}
+		}
+		// Reopen 打开的文件由写入器关闭
+		if wa.ownsWriter {
+			_ = wa.writer.(io.Closer).Close()
+		}
 		wa.bufferedWriter.Reset(io.Discard)
+		writerRegistry.remove(wa)
 	})
 }
 
@@ -102,16 +198,258 @@ func (wa *WriteAsyncer) Write(p []byte) (n int, err error) {
 		return 0, nil
 	}
 
-	buff := wa.bufferpool.GetWithHint(l)
-	if buff.Cap() < l {
-		buff.Grow(l - buff.Cap())
+	if !wa.sample(p) {
+		return l, nil
 	}
 
+	size := l
+	if wa.conf().ensureNewline && p[l-1] != '\n' {
+		size++
+	}
+
+	if wa.limiter.Exceeds(size) {
+		return wa.writeOversize(p)
+	}
+
+	buff := wa.bufferpool.GetWithHint(size)
 	if n, err = buff.Write(p); err != nil {
 		wa.bufferpool.Put(buff)
 		return 0, err
 	}
+	if size > l {
+		buff.WriteByte('\n')
+	}
+
+	wa.enqueueBuffer(buff)
+	return l, nil
+}
+
+// sample 判断记录是否通过采样器，被丢弃时计入 SuppressedRecords
+func (wa *WriteAsyncer) sample(p []byte) bool {
+	if s := wa.conf().sampler; s != nil && !s.Allow(p) {
+		wa.counters.SuppressedRecords.Add(1)
+		return false
+	}
+	return true
+}
+
+// enqueueBuffer 将已填充的缓冲区入队，缓冲区由轮询器写出后归还到缓冲池
+func (wa *WriteAsyncer) enqueueBuffer(buff *bytes.Buffer) {
+	l := buff.Len()
+	element := wr.Element{Buffer: buff}
+	if wa.latency != nil {
+		element.EnqueuedAt = utils.Nanotime()
+	}
+
+	wa.queue.Push(element)
+	wa.counters.EnqueuedRecords.Add(1)
+	wa.counters.EnqueuedBytes.Add(uint64(l))
+}
+
+// AcquireBuffer 从写入器的缓冲池获取一个空缓冲区。
+// 填充后通过 WriteOwned 交给写入器，不再使用时通过 ReleaseBuffer 归还。
+func (wa *WriteAsyncer) AcquireBuffer() *bytes.Buffer {
+	return wa.bufferpool.Get()
+}
+
+// ReleaseBuffer 将未交给写入器的缓冲区归还到缓冲池
+func (wa *WriteAsyncer) ReleaseBuffer(buff *bytes.Buffer) {
+	wa.bufferpool.Put(buff)
+}
+
+// WriteOwned 将缓冲区的所有权交给写入器，不复制其中的数据，返回入队的字节数。
+// 无论是否返回错误，缓冲区都由写入器接管并最终归还到缓冲池，调用方不应再读写它。
+// 缓冲区不要求来自 AcquireBuffer。
+func (wa *WriteAsyncer) WriteOwned(buff *bytes.Buffer) (int, error) {
+	if buff == nil {
+		return 0, ErrorWriteContentIsNil
+	}
+
+	if !wa.state.IsRunning() {
+		wa.bufferpool.Put(buff)
+		return 0, ErrorWriteAsyncerIsClosed
+	}
+
+	if buff.Len() == 0 {
+		wa.bufferpool.Put(buff)
+		return 0, nil
+	}
+
+	if !wa.sample(buff.Bytes()) {
+		l := buff.Len()
+		wa.bufferpool.Put(buff)
+		return l, nil
+	}
+
+	if wa.conf().ensureNewline {
+		wr.EnsureNewline(buff)
+	}
+	l := buff.Len()
 
-	wa.queue.Push(buff)
+	if wa.limiter.Exceeds(l) {
+		n, err := wa.writeOversize(buff.Bytes())
+		wa.bufferpool.Put(buff)
+		return n, err
+	}
+
+	wa.enqueueBuffer(buff)
 	return l, nil
 }
+
+// WriteRecord 将记录入队，编码延迟到轮询器协程上执行。
+// 无论是否返回错误，记录都由写入器接管，调用方不应再修改；实现了 Releaser 的记录会在编码后
+// 或被拒绝时回收。使用自定义队列时，记录会在调用方协程上立即编码。
+func (wa *WriteAsyncer) WriteRecord(record Record) error {
+	if record == nil {
+		return ErrorWriteContentIsNil
+	}
+
+	if !wa.state.IsRunning() {
+		wr.ReleaseRecord(record)
+		return ErrorWriteAsyncerIsClosed
+	}
+
+	if !wa.sample(nil) {
+		wr.ReleaseRecord(record)
+		return nil
+	}
+
+	element := wr.Element{Record: record}
+	if wa.conf().queue != nil {
+		buff := wa.bufferpool.Get()
+		err := record.EncodeTo(buff)
+		wr.ReleaseRecord(record)
+		if err != nil {
+			wa.bufferpool.Put(buff)
+			return err
+		}
+		if wa.conf().ensureNewline {
+			wr.EnsureNewline(buff)
+		}
+		if wa.limiter.Exceeds(buff.Len()) {
+			_, err = wa.writeOversize(buff.Bytes())
+			wa.bufferpool.Put(buff)
+			return err
+		}
+		element = wr.Element{Buffer: buff}
+		wa.counters.EnqueuedBytes.Add(uint64(buff.Len()))
+	}
+
+	if wa.latency != nil {
+		element.EnqueuedAt = utils.Nanotime()
+	}
+
+	wa.queue.Push(element)
+	wa.counters.EnqueuedRecords.Add(1)
+	return nil
+}
+
+// Flush 将队列中已有的数据写出并刷新到底层 io.Writer。
+// 刷新在轮询器协程上执行，调用会阻塞到刷新完成。
+func (wa *WriteAsyncer) Flush() error {
+	return wa.flush(context.Background())
+}
+
+// FlushContext 与 Flush 相同，但 ctx 结束时不再等待并返回 ctx.Err()，已开始的刷新仍会在轮询器协程上完成
+func (wa *WriteAsyncer) FlushContext(ctx context.Context) error {
+	return wa.flush(ctx)
+}
+
+// flush 在轮询器协程上刷新，ctx 结束时放弃提交或不再等待
+func (wa *WriteAsyncer) flush(ctx context.Context) error {
+	if !wa.state.IsRunning() {
+		return ErrorWriteAsyncerIsClosed
+	}
+	return wa.callContext(ctx, wa.poller.Sync)
+}
+
+// call 在轮询器协程上执行 fn，轮询器已停止时返回 ErrorWriteAsyncerIsClosed
+func (wa *WriteAsyncer) call(fn func() error) error {
+	return wa.callContext(context.Background(), fn)
+}
+
+// callContext 在轮询器协程上执行 fn，ctx 结束时放弃提交
+func (wa *WriteAsyncer) callContext(ctx context.Context, fn func() error) error {
+	err := wa.poller.Call(ctx, fn)
+	if errors.Is(err, poller.ErrorPollerStopped) {
+		return ErrorWriteAsyncerIsClosed
+	}
+	return err
+}
+
+// Config 返回写入器当前配置的副本，修改副本不会影响写入器
+func (wa *WriteAsyncer) Config() *Config {
+	return wa.conf().clone()
+}
+
+// conf 返回写入器当前使用的配置，返回的配置不能被修改
+func (wa *WriteAsyncer) conf() *Config {
+	return wa.config.Load()
+}
+
+// WriteError 一次写入或刷新失败的记录
+type WriteError struct {
+	At    time.Time // 发生时间
+	Err   error     // 失败原因
+	Bytes int       // 受影响的字节数
+}
+
+// RecentErrors 按时间从新到旧返回最近的写入和刷新失败记录
+func (wa *WriteAsyncer) RecentErrors() []WriteError {
+	events := wa.errors.Events()
+	errs := make([]WriteError, 0, len(events))
+	for _, e := range events {
+		errs = append(errs, WriteError{At: e.At, Err: e.Err, Bytes: e.Bytes})
+	}
+	return errs
+}
+
+// Stats 返回写入器的运行状态快照
+func (wa *WriteAsyncer) Stats() Stats {
+	stats := Stats{
+		Running:           wa.state.IsRunning(),
+		QueueLength:       -1,
+		BufferedBytes:     int(wa.counters.BufferedBytes.Load()),
+		EnqueuedRecords:   wa.counters.EnqueuedRecords.Load(),
+		EnqueuedBytes:     wa.counters.EnqueuedBytes.Load(),
+		WrittenRecords:    wa.counters.WrittenRecords.Load(),
+		WrittenBytes:      wa.counters.WrittenBytes.Load(),
+		FailedRecords:     wa.counters.FailedRecords.Load(),
+		Flushes:           wa.counters.Flushes.Load(),
+		FlushErrors:       wa.counters.FlushErrors.Load(),
+		RejectedRecords:   wa.counters.RejectedRecords.Load(),
+		TruncatedRecords:  wa.counters.TruncatedRecords.Load(),
+		SplitRecords:      wa.counters.SplitRecords.Load(),
+		FilteredRecords:   wa.counters.FilteredRecords.Load(),
+		TransformErrors:   wa.counters.TransformErrors.Load(),
+		SuppressedRecords: wa.counters.SuppressedRecords.Load(),
+		RepeatedRecords:   wa.counters.RepeatedRecords.Load(),
+		Paused:            wa.poller.Paused(),
+		DiscardedRecords:  wa.counters.DiscardedRecords.Load(),
+		Latency:           wa.LatencyStats(),
+		BufferPool:        wa.pool.Stats(),
+	}
+
+	if q, ok := wa.queue.(interface{ Len() int }); ok {
+		stats.QueueLength = q.Len()
+	} else if q, ok := wa.conf().queue.(interface{ Len() int }); ok {
+		stats.QueueLength = q.Len()
+	}
+
+	if at := wa.counters.LastFlushAt.Load(); at > 0 {
+		stats.LastFlushAt = time.Unix(0, at)
+	}
+
+	return stats
+}
+
+// LatencyStats 返回延迟统计快照，未开启 WithLatencyStats 时返回零值
+func (wa *WriteAsyncer) LatencyStats() LatencyStats {
+	if wa.latency == nil {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		QueueDelay:    newHistogramSnapshot(wa.latency.queueDelay),
+		FlushDuration: newHistogramSnapshot(wa.latency.flushDuration),
+	}
+}
@@ -34,6 +34,28 @@ func (fw *faultyWriter) Write(p []byte) (n int, err error) {
 	return 0, errorWriteFailed
 }
 
+type sliceQueue struct {
+	mu    sync.Mutex
+	items []*bytes.Buffer
+}
+
+func (q *sliceQueue) Push(value *bytes.Buffer) {
+	q.mu.Lock()
+	q.items = append(q.items, value)
+	q.mu.Unlock()
+}
+
+func (q *sliceQueue) Pop() *bytes.Buffer {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil
+	}
+	value := q.items[0]
+	q.items = q.items[1:]
+	return value
+}
+
 func TestWriteAsyncer_Standard(t *testing.T) {
 	buff := bytes.NewBuffer(make([]byte, 0, 1024))
 
@@ -152,6 +174,18 @@ func TestWriteAsyncer_EdgeCases(t *testing.T) {
 		assert.Equal(t, 0, n)
 	})
 
+	t.Run("custom queue", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0))
+		w := NewWriteAsyncer(buff, NewConfig().WithQueue(&sliceQueue{}))
+
+		_, err := w.Write([]byte("hello"))
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, "hello", buff.String())
+		assert.Equal(t, -1, w.Stats().QueueLength)
+	})
+
 	t.Run("multiple stop calls", func(t *testing.T) {
 		buff := bytes.NewBuffer(make([]byte, 0))
 		w := NewWriteAsyncer(buff, nil)
@@ -242,3 +276,97 @@ func TestWriteAsyncer_BufferHandling(t *testing.T) {
 		assert.Equal(t, "test", buff.String())
 	})
 }
+
+func TestWriteAsyncer_Flush(t *testing.T) {
+	t.Run("flush writes queued content", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+		defer w.Stop()
+
+		_, err := w.Write([]byte("hello"))
+		assert.Nil(t, err)
+
+		assert.Nil(t, w.Flush())
+		assert.Equal(t, "hello", buff.String())
+		assert.Equal(t, 0, w.Stats().BufferedBytes)
+	})
+
+	t.Run("flush after stop", func(t *testing.T) {
+		w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+		w.Stop()
+
+		assert.ErrorIs(t, w.Flush(), ErrorWriteAsyncerIsClosed)
+	})
+
+	t.Run("flush failure is reported", func(t *testing.T) {
+		w := NewWriteAsyncer(&faultyWriter{}, nil)
+		defer w.Stop()
+
+		_, err := w.Write([]byte("hello"))
+		assert.Nil(t, err)
+
+		assert.ErrorIs(t, w.Flush(), errorWriteFailed)
+
+		errs := w.RecentErrors()
+		assert.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0].Err, errorWriteFailed)
+		assert.Equal(t, 5, errs[0].Bytes)
+		assert.Equal(t, uint64(1), w.Stats().FlushErrors)
+	})
+}
+
+func TestWriteAsyncer_Config(t *testing.T) {
+	conf := NewConfig().WithBufferSize(128).WithIdleTimeout(time.Second)
+	w := NewWriteAsyncer(bytes.NewBuffer(nil), conf)
+	defer w.Stop()
+
+	got := w.Config()
+	assert.Equal(t, 128, got.BufferSize())
+	assert.Equal(t, time.Second, got.IdleTimeout())
+	assert.Equal(t, DefaultHeartbeatInterval, got.HeartbeatInterval())
+
+	got.WithBufferSize(1)
+	assert.Equal(t, 128, w.Config().BufferSize())
+}
+
+func TestWriteAsyncer_WriteOwned(t *testing.T) {
+	t.Run("owned buffers are written without copy", func(t *testing.T) {
+		buff := bytes.NewBuffer(make([]byte, 0, 1024))
+		w := NewWriteAsyncer(buff, nil)
+
+		for i := 0; i < 3; i++ {
+			b := w.AcquireBuffer()
+			assert.Equal(t, 0, b.Len())
+			fmt.Fprintf(b, "line %d\n", i)
+
+			n, err := w.WriteOwned(b)
+			assert.Nil(t, err)
+			assert.Equal(t, 7, n)
+		}
+
+		_, err := w.Write([]byte("raw\n"))
+		assert.Nil(t, err)
+		w.Stop()
+
+		assert.Equal(t, "line 0\nline 1\nline 2\nraw\n", buff.String())
+		assert.Equal(t, uint64(4), w.Stats().WrittenRecords)
+	})
+
+	t.Run("nil, empty and closed", func(t *testing.T) {
+		w := NewWriteAsyncer(bytes.NewBuffer(nil), nil)
+
+		_, err := w.WriteOwned(nil)
+		assert.ErrorIs(t, err, ErrorWriteContentIsNil)
+
+		n, err := w.WriteOwned(w.AcquireBuffer())
+		assert.Nil(t, err)
+		assert.Equal(t, 0, n)
+
+		b := w.AcquireBuffer()
+		w.ReleaseBuffer(b)
+		w.Stop()
+
+		_, err = w.WriteOwned(bytes.NewBufferString("late"))
+		assert.ErrorIs(t, err, ErrorWriteAsyncerIsClosed)
+	})
+}